@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/auth"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// Invite link expiry when not specified, and the maximum allowed.
+	defaultInviteTTL = 7 * 24 * time.Hour
+	maxInviteTTL     = 30 * 24 * time.Hour
+
+	// Passcode length limits, bcrypt only uses the first 72 bytes.
+	minPasscodeLength = 8
+	maxPasscodeLength = 72
+
+	// Maximum size of JSON request body.
+	maxJSONBodySize = 4096
+
+	// Maximum number of boards the session keeps access of, cookie can only hold about 4KB.
+	maxSessionBoards = 20
+)
+
+// sessionBoardsKey is the session key of boards the session was granted access to, least recent first
+const sessionBoardsKey = "boards"
+
+// accessKey is the session key of access granted to private board
+func accessKey(boardID uuid.UUID) string {
+	return "access_" + boardID.String()
+}
+
+// canAccess checks whether user can join the board.
+// Private board can be joined by its facilitator, or by users granted access with current access epoch.
+func canAccess(session *sessions.Session, b *models.Board, user *models.User) bool {
+	if !b.Private || b.FacilitatorID == user.ID {
+		return true
+	}
+	return hasAccessGrant(session, b)
+}
+
+// hasAccessGrant checks whether the session was granted access to the board with passcode or invite
+func hasAccessGrant(session *sessions.Session, b *models.Board) bool {
+	epoch, ok := session.Values[accessKey(b.ID)].(int)
+	return ok && epoch == b.AccessEpoch
+}
+
+// grantAccess grants access to the board to the session, until the board access epoch changed
+func grantAccess(session *sessions.Session, b *models.Board) {
+	rememberBoard(session, b.ID)
+	session.Values[accessKey(b.ID)] = b.AccessEpoch
+	delete(session.Values, viewKey(b.ID))
+}
+
+// rememberBoard records the board as most recently granted, access to the least recent boards
+// is dropped when the session holds more than maxSessionBoards.
+func rememberBoard(session *sessions.Session, boardID uuid.UUID) {
+	boards, _ := session.Values[sessionBoardsKey].([]uuid.UUID)
+	boards = slices.DeleteFunc(boards, func(id uuid.UUID) bool { return id == boardID })
+	boards = append(boards, boardID)
+	for len(boards) > maxSessionBoards {
+		delete(session.Values, accessKey(boards[0]))
+		delete(session.Values, viewKey(boards[0]))
+		boards = boards[1:]
+	}
+	session.Values[sessionBoardsKey] = boards
+}
+
+// viewKey is the session key of view-only access granted with view link
+func viewKey(boardID uuid.UUID) string {
+	return "view_" + boardID.String()
+}
+
+// isSpectator checks whether user joins the board as spectator, i.e the session opened its view link.
+// Facilitator always participates.
+func isSpectator(session *sessions.Session, b *models.Board, user *models.User) bool {
+	if b.FacilitatorID == user.ID {
+		return false
+	}
+	epoch, ok := session.Values[viewKey(b.ID)].(int)
+	return ok && epoch == b.AccessEpoch
+}
+
+// grantView grants view-only access to the board to the session, the user joins it as spectator until the board access epoch changed
+func grantView(session *sessions.Session, b *models.Board) {
+	rememberBoard(session, b.ID)
+	session.Values[viewKey(b.ID)] = b.AccessEpoch
+}
+
+// acceptInvite grants access to the board when the invite token is valid for it.
+// View link doesn't make participants of the board spectators, they keep their role.
+func (a *app) acceptInvite(ctx context.Context, session *sessions.Session, b *models.Board, user *models.User, token string) error {
+	invite, err := auth.VerifyInvite(a.config.secret, token, time.Now())
+	if err != nil {
+		return err
+	}
+	if invite.BoardID != b.ID || invite.Epoch != b.AccessEpoch {
+		return errors.New("invite revoked")
+	}
+	if !invite.ViewOnly {
+		grantAccess(session, b)
+		return nil
+	}
+
+	if b.FacilitatorID == user.ID || hasAccessGrant(session, b) {
+		return nil
+	}
+	participant, err := a.store.Participants.Has(ctx, b.ID, user.ID)
+	if err != nil {
+		return fmt.Errorf("error a.store.Participants.Has: %s", err.Error())
+	}
+	if !participant {
+		grantView(session, b)
+	}
+	return nil
+}
+
+// readJSON decodes JSON request body into dst.
+// Only JSON body accepted, browsers don't send it cross-site without CORS preflight so it's CSRF safe.
+func readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return errors.New("content type must be application/json")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodySize))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// requestBoard returns board of the request along with session and its user
+func (a *app) requestBoard(w http.ResponseWriter, r *http.Request) (*models.Board, *sessions.Session, *models.User, bool) {
+	ctx := r.Context()
+	boardID, err := uuid.Parse(r.PathValue("board"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return nil, nil, nil, false
+	}
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return nil, nil, nil, false
+	}
+	b, err := a.store.Boards.Get(ctx, boardID)
+	if errors.Is(err, store.ErrDeleted) {
+		a.clientError(w, r, http.StatusGone, err)
+		return nil, nil, nil, false
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return nil, nil, nil, false
+	}
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Boards.Get: %s", err.Error()))
+		return nil, nil, nil, false
+	}
+	return b, session, user, true
+}
+
+// facilitatorBoard returns board of the request when session user is its facilitator
+func (a *app) facilitatorBoard(w http.ResponseWriter, r *http.Request) (*models.Board, bool) {
+	b, _, user, ok := a.requestBoard(w, r)
+	if !ok {
+		return nil, false
+	}
+	if b.FacilitatorID != user.ID {
+		a.clientError(w, r, http.StatusForbidden, errors.New("only facilitator can manage the board"))
+		return nil, false
+	}
+	return b, true
+}
+
+// boardAccess grants access to private board with its passcode
+func (a *app) boardAccess(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Passcode string `json:"passcode"`
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	b, session, _, ok := a.requestBoard(w, r)
+	if !ok {
+		return
+	}
+
+	if b.Private {
+		if !a.passcodeAttempts.allow(b.ID.String() + "/" + clientIP(r, a.config.trustedProxies)) {
+			a.clientError(w, r, http.StatusTooManyRequests, errors.New("too many passcode attempts, try again later"))
+			return
+		}
+		hash, err := a.store.Boards.GetPasscode(r.Context(), b.ID)
+		if err != nil {
+			a.serverError(w, r, fmt.Errorf("error a.store.Boards.GetPasscode: %s", err.Error()))
+			return
+		}
+		if hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(input.Passcode)) != nil {
+			a.clientError(w, r, http.StatusForbidden, errors.New("wrong passcode"))
+			return
+		}
+		grantAccess(session, b)
+		if err := session.Save(r, w); err != nil {
+			a.serverError(w, r, err)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateBoardAccess makes board private or public and sets its passcode.
+// Changing the passcode revokes access granted with the old one, as well as invites.
+func (a *app) updateBoardAccess(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Private  *bool   `json:"private"`
+		Passcode *string `json:"passcode"` // empty removes the passcode
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+
+	if input.Passcode != nil {
+		passcode := *input.Passcode
+		if passcode != "" && (len(passcode) < minPasscodeLength || len(passcode) > maxPasscodeLength) {
+			a.clientErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("passcode must be %d to %d characters", minPasscodeLength, maxPasscodeLength))
+			return
+		}
+		var hash []byte
+		if passcode != "" {
+			var err error
+			if hash, err = bcrypt.GenerateFromPassword([]byte(passcode), bcrypt.DefaultCost); err != nil {
+				a.serverError(w, r, err)
+				return
+			}
+		}
+		if err := a.store.Boards.SetPasscode(ctx, b.ID, string(hash)); err != nil {
+			a.serverError(w, r, fmt.Errorf("error a.store.Boards.SetPasscode: %s", err.Error()))
+			return
+		}
+	}
+	_, err := a.store.Boards.Update(ctx, b.ID, func(b *models.Board) error {
+		if input.Passcode != nil {
+			b.AccessEpoch++
+		}
+		if input.Private != nil {
+			b.Private = *input.Private
+		}
+		return nil
+	})
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Boards.Update: %s", err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createInvite creates invite link to the board, or view link that lets its users only watch the board
+func (a *app) createInvite(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TTL      string `json:"ttl"` // e.g 24h
+		ViewOnly bool   `json:"view_only"`
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	ttl := defaultInviteTTL
+	if input.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(input.TTL); err != nil || ttl <= 0 || ttl > maxInviteTTL {
+			a.clientErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("invalid invite ttl %q, maximum %s", input.TTL, maxInviteTTL))
+			return
+		}
+	}
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+
+	invite := auth.Invite{BoardID: b.ID, Epoch: b.AccessEpoch, ExpiresAt: time.Now().Add(ttl), ViewOnly: input.ViewOnly}
+	token := auth.SignInvite(a.config.secret, invite)
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"url":        fmt.Sprintf("/b/%s?invite=%s", b.ID, token),
+		"expires_at": invite.ExpiresAt.Unix(),
+		"view_only":  invite.ViewOnly,
+	})
+}
+
+// revokeInvites revokes all invites and view links of the board, along with access granted by them
+func (a *app) revokeInvites(w http.ResponseWriter, r *http.Request) {
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	_, err := a.store.Boards.Update(r.Context(), b.ID, func(b *models.Board) error {
+		b.AccessEpoch++
+		return nil
+	})
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Boards.Update: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("board invites revoked", "id", b.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_grantAccess_capped(t *testing.T) {
+	cookies := sessions.NewCookieStore([]byte("test-secret-test-secret-test-sec"))
+	session := sessions.NewSession(cookies, SESSION_NAME)
+
+	var boards []*models.Board
+	for i := range maxSessionBoards + 5 {
+		b := &models.Board{ID: uuid.New(), Private: true, AccessEpoch: 1}
+		boards = append(boards, b)
+		if i%2 == 0 {
+			grantAccess(session, b)
+		} else {
+			grantView(session, b)
+		}
+	}
+	// granting again makes the board most recent, so the next one is dropped instead
+	grantAccess(session, boards[5])
+	b := &models.Board{ID: uuid.New(), Private: true, AccessEpoch: 1}
+	boards = append(boards, b)
+	grantAccess(session, b)
+
+	user := &models.User{ID: uuid.New()}
+	for i, b := range boards {
+		granted := canAccess(session, b, user) || isSpectator(session, b, user)
+		assert.Equal(t, i == 5 || i > 6, granted, "board %d", i)
+	}
+	assert.Len(t, session.Values[sessionBoardsKey], maxSessionBoards)
+
+	// session still fits in cookie
+	w := httptest.NewRecorder()
+	require.NoError(t, session.Save(httptest.NewRequest("GET", "/", nil), w))
+	assert.Less(t, len(w.Header().Get("Set-Cookie")), 4096)
+}
+
+func Test_privateBoard(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL)
+	boardID := uuid.New()
+	boardURL := fmt.Sprintf("%s/b/%s", testURL, boardID)
+	ctx := context.Background()
+
+	// board creator is the facilitator
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, boardID))
+
+	bob := testBrowser(t, a)
+	code, _ := postJSON(t, bob, http.MethodPost, boardURL+"/settings/access", map[string]any{"private": true})
+	assert.Equal(t, http.StatusUnauthorized, code)
+	require.Equal(t, http.StatusOK, visit(t, bob, boardID))
+	code, _ = postJSON(t, bob, http.MethodPost, boardURL+"/settings/access", map[string]any{"private": true})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = postJSON(t, alice, http.MethodPost, boardURL+"/settings/access", map[string]any{"private": true, "passcode": "open-sesame"})
+	require.Equal(t, http.StatusNoContent, code)
+	b, err := a.store.Boards.Get(ctx, boardID)
+	require.NoError(t, err)
+	assert.True(t, b.Private)
+
+	// others need passcode or invite, both for the page and the websocket
+	carol := testBrowser(t, a)
+	assert.Equal(t, http.StatusForbidden, visit(t, carol, boardID))
+	assert.Empty(t, wsTicket(carol, testURL, boardID))
+
+	code, _ = postJSON(t, carol, http.MethodPost, boardURL+"/access", map[string]any{"passcode": "wrong"})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = postJSON(t, carol, http.MethodPost, boardURL+"/access", map[string]any{"passcode": "open-sesame"})
+	require.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, http.StatusOK, visit(t, carol, boardID))
+	assert.NotEmpty(t, wsTicket(carol, testURL, boardID))
+
+	// invite link grants access then redirects to the board
+	code, invite := postJSON(t, alice, http.MethodPost, boardURL+"/invites", map[string]any{"ttl": "1h"})
+	require.Equal(t, http.StatusCreated, code)
+	dave := testBrowser(t, a)
+	resp, err := dave.Get(testURL + invite["url"].(string))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, boardURL, resp.Request.URL.String())
+
+	// invite of other board doesn't work
+	otherID := uuid.New()
+	require.Equal(t, http.StatusOK, visit(t, alice, otherID))
+	code, other := postJSON(t, alice, http.MethodPost, fmt.Sprintf("%s/b/%s/invites", testURL, otherID), map[string]any{})
+	require.Equal(t, http.StatusCreated, code)
+	eve := testBrowser(t, a)
+	otherToken := other["url"].(string)[strings.Index(other["url"].(string), "?"):]
+	assert.Equal(t, http.StatusForbidden, visit(t, eve, boardID))
+	resp, err = eve.Get(boardURL + otherToken)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// revoking invites revokes access granted by them and by passcode, facilitator keeps access
+	code, _ = postJSON(t, alice, http.MethodDelete, boardURL+"/invites", nil)
+	require.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, http.StatusForbidden, visit(t, dave, boardID))
+	assert.Equal(t, http.StatusForbidden, visit(t, carol, boardID))
+	assert.Equal(t, http.StatusOK, visit(t, alice, boardID))
+	resp, err = eve.Get(testURL + invite["url"].(string))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// form posts are rejected (CSRF)
+	resp, err = carol.PostForm(boardURL+"/access", url.Values{"passcode": {"open-sesame"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// passcode guessing is rate limited per board and client IP, and too short passcode rejected
+	code = 0
+	for range passcodeAttemptBurst {
+		if code, _ = postJSON(t, carol, http.MethodPost, boardURL+"/access", map[string]any{"passcode": "guess"}); code != http.StatusForbidden {
+			break
+		}
+	}
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	code, _ = postJSON(t, alice, http.MethodPost, boardURL+"/settings/access", map[string]any{"passcode": "1234"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// public again
+	code, _ = postJSON(t, alice, http.MethodPost, boardURL+"/settings/access", map[string]any{"private": false, "passcode": ""})
+	require.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, http.StatusOK, visit(t, dave, boardID))
+}
+
+func Test_spectator(t *testing.T) {
+	srv := natstest.Server(t)
+	instance := testInstance(t, srv)
+	boardID := uuid.New()
+	boardURL := fmt.Sprintf("%s/b/%s", instance.URL, boardID)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	alice := &http.Client{Jar: jar}
+	aliceWS := joinWith(t, alice, instance, boardID, "alice")
+
+	code, link := postJSON(t, alice, http.MethodPost, boardURL+"/invites", map[string]any{"view_only": true})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, true, link["view_only"])
+
+	// view link makes the user spectator, even on public board
+	jar, err = cookiejar.New(nil)
+	require.NoError(t, err)
+	carol := &http.Client{Jar: jar}
+	resp, err := carol.Get(instance.URL + link["url"].(string))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	carolWS := joinWith(t, carol, instance, boardID, "carol")
+	aliceWS.waitForObject("clients", func(obj map[string]any) bool {
+		return obj["user"].(map[string]any)["name"] == "carol" && obj["spectator"] == true
+	})
+
+	// spectator receives the board, but can't change it
+	good := carolWS.waitForObject("columns", func(obj map[string]any) bool { return obj["name"] == "Good" })
+	carolWS.send("card.new", map[string]any{"name": "spam", "column_id": good["id"]})
+	carolWS.send("timer.cmd", map[string]any{"cmd": "start", "value": "1m"})
+	carolWS.waitFor("board.notification", func(m map[string]any) bool { return strings.Contains(m["data"].(string), "watching") })
+	aliceWS.send("card.new", map[string]any{"name": "real", "column_id": good["id"]})
+	carolWS.waitForObject("cards", func(obj map[string]any) bool { return obj["name"] == "real" })
+	_, found := carolWS.find("cards", func(m map[string]any) bool { return m["obj"].(map[string]any)["name"] == "spam" })
+	assert.False(t, found)
+	_, found = carolWS.find("timer.state", func(m map[string]any) bool { return true })
+	assert.False(t, found)
+
+	// participant opening the view link stays participant
+	jar, err = cookiejar.New(nil)
+	require.NoError(t, err)
+	bob := &http.Client{Jar: jar}
+	joinWith(t, bob, instance, boardID, "bob").conn.Close()
+	resp, err = bob.Get(instance.URL + link["url"].(string))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	bobWS := joinWith(t, bob, instance, boardID, "bob")
+	bobWS.send("card.new", map[string]any{"name": "from bob", "column_id": good["id"]})
+	aliceWS.waitForObject("cards", func(obj map[string]any) bool { return obj["name"] == "from bob" })
+
+	// spectator can still watch the board once it's private, until the links are revoked
+	code, _ = postJSON(t, alice, http.MethodPost, boardURL+"/settings/access", map[string]any{"private": true})
+	require.Equal(t, http.StatusNoContent, code)
+	resp, err = carol.Get(boardURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(boardURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	code, _ = postJSON(t, alice, http.MethodDelete, boardURL+"/invites", nil)
+	require.Equal(t, http.StatusNoContent, code)
+	resp, err = carol.Get(boardURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/auth"
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+// apiUserKey is the context key of the user authenticated with API token
+const apiUserKey contextKey = "api_user"
+
+// maxBoardsListed is the maximum number of boards listed for the API user
+const maxBoardsListed = 1000
+
+// apiRoutes returns routes of the REST API, every request must be authenticated with API token.
+// Changes are made by the same message handler as websocket messages, so websocket clients see them the same way.
+func (a *app) apiRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/boards", a.apiListBoards)
+	mux.HandleFunc("POST /api/boards", a.apiCreateBoard)
+	mux.HandleFunc("GET /api/boards/{board}", a.apiGetBoard)
+	mux.HandleFunc("PATCH /api/boards/{board}", a.apiMessage("board.update", http.StatusOK))
+	mux.HandleFunc("DELETE /api/boards/{board}", a.apiMessage("board.delete", http.StatusNoContent))
+	mux.HandleFunc("POST /api/boards/{board}/lock", a.apiMessage("board.lock", http.StatusOK))
+	mux.HandleFunc("POST /api/boards/{board}/archive", a.apiMessage("board.archive", http.StatusOK))
+	mux.HandleFunc("POST /api/boards/{board}/columns", a.apiMessage("column.new", http.StatusCreated))
+	mux.HandleFunc("PATCH /api/boards/{board}/columns/{id}", a.apiMessage("column.update", http.StatusOK))
+	mux.HandleFunc("DELETE /api/boards/{board}/columns/{id}", a.apiMessage("column.delete", http.StatusNoContent))
+	mux.HandleFunc("POST /api/boards/{board}/cards", a.apiMessage("card.new", http.StatusCreated))
+	mux.HandleFunc("PATCH /api/boards/{board}/cards/{id}", a.apiMessage("card.update", http.StatusOK))
+	mux.HandleFunc("DELETE /api/boards/{board}/cards/{id}", a.apiMessage("card.delete", http.StatusNoContent))
+	mux.HandleFunc("POST /api/boards/{board}/cards/{id}/votes", a.apiMessage("card.vote", http.StatusOK))
+	return a.apiAuth(mux)
+}
+
+// apiAuth authenticates request with API token in Authorization header (Bearer scheme).
+// Session cookie is not accepted, so the API is not exposed to cross-site requests.
+func (a *app) apiAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			a.apiError(w, r, http.StatusUnauthorized, errors.New("api token required"))
+			return
+		}
+		user, err := a.apiTokenUser(r.Context(), token)
+		if errors.Is(err, auth.ErrInvalidAPIToken) || errors.Is(err, auth.ErrAPITokenExpired) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			a.apiError(w, r, http.StatusUnauthorized, err)
+			return
+		}
+		if err != nil {
+			a.apiError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiUserKey, user)))
+	})
+}
+
+// apiTokenUser returns the user of API token.
+// The user record expires like other records when the user is inactive, the token still acts as anonymous user with the same ID.
+func (a *app) apiTokenUser(ctx context.Context, token string) (*models.User, error) {
+	userID, id, secret, err := auth.ParseAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+	record, err := a.store.Tokens.Get(ctx, userID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, auth.ErrInvalidAPIToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error a.store.Tokens.Get: %s", err.Error())
+	}
+	if err := auth.VerifyAPIToken(record, secret, time.Now()); err != nil {
+		return nil, err
+	}
+
+	user, err := a.store.Users.Get(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return &models.User{ID: userID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error a.store.Users.Get: %s", err.Error())
+	}
+	return user, nil
+}
+
+// apiError responds with JSON error, message of server errors is not exposed
+func (a *app) apiError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	msg := err.Error()
+	if code >= http.StatusInternalServerError {
+		a.requestLogger(r).Error(msg, "type", "server-error", "method", r.Method, "uri", logURI(r.URL))
+		msg = http.StatusText(code)
+	} else {
+		a.requestLogger(r).Error(msg, "type", "client-error", "method", r.Method, "uri", logURI(r.URL))
+	}
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+// apiHandleError responds with status matching the error returned by board.BoardManager.Handle
+func (a *app) apiHandleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, board.ErrInvalidMessage):
+		a.apiError(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, store.ErrDeleted):
+		a.apiError(w, r, http.StatusGone, errors.New("board deleted"))
+	case errors.Is(err, store.ErrNotFound):
+		a.apiError(w, r, http.StatusNotFound, errors.New("not found"))
+	case errors.Is(err, board.ErrNotAuthenticated), errors.Is(err, board.ErrNotFacilitator):
+		a.apiError(w, r, http.StatusForbidden, err)
+	case errors.Is(err, board.ErrBoardReadOnly):
+		a.apiError(w, r, http.StatusLocked, err)
+	case errors.Is(err, board.ErrBoardLimitReached):
+		a.apiError(w, r, http.StatusConflict, err)
+	case errors.Is(err, board.ErrRateLimited):
+		a.apiError(w, r, http.StatusTooManyRequests, err)
+	default:
+		a.apiError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// apiBoard returns board of the request along with the API user, when the user can access it.
+// Access to private board is granted to sessions, so only its facilitator can use it via API.
+func (a *app) apiBoard(w http.ResponseWriter, r *http.Request) (*models.Board, *models.User, bool) {
+	user := r.Context().Value(apiUserKey).(*models.User)
+	boardID, err := uuid.Parse(r.PathValue("board"))
+	if err != nil {
+		a.apiError(w, r, http.StatusNotFound, errors.New("not found"))
+		return nil, nil, false
+	}
+	b, err := a.store.Boards.Get(r.Context(), boardID)
+	if errors.Is(err, store.ErrDeleted) {
+		a.apiError(w, r, http.StatusGone, errors.New("board deleted"))
+		return nil, nil, false
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		a.apiError(w, r, http.StatusNotFound, errors.New("not found"))
+		return nil, nil, false
+	}
+	if err != nil {
+		a.apiError(w, r, http.StatusInternalServerError, fmt.Errorf("error a.store.Boards.Get: %s", err.Error()))
+		return nil, nil, false
+	}
+	if b.RequireAuth && !user.Authenticated {
+		a.apiError(w, r, http.StatusForbidden, errors.New("board requires authenticated user"))
+		return nil, nil, false
+	}
+	if b.Private && b.FacilitatorID != user.ID {
+		a.apiError(w, r, http.StatusForbidden, errors.New("private board can only be used by its facilitator"))
+		return nil, nil, false
+	}
+	return b, user, true
+}
+
+// apiListBoards returns boards facilitated by the API user, newest first.
+// Archived boards are hidden unless ?archived=true given.
+func (a *app) apiListBoards(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(apiUserKey).(*models.User)
+	includeArchived := r.URL.Query().Get("archived") == "true"
+	all, err := a.store.Boards.ListByFacilitator(r.Context(), user.ID, maxBoardsListed)
+	if err != nil {
+		a.apiError(w, r, http.StatusInternalServerError, fmt.Errorf("error a.store.Boards.ListByFacilitator: %s", err.Error()))
+		return
+	}
+	boards := []models.Board{}
+	for _, b := range all {
+		if includeArchived || !b.Archived {
+			boards = append(boards, b)
+		}
+	}
+	slices.SortFunc(boards, func(x, y models.Board) int { return cmp.Compare(y.CreatedAt, x.CreatedAt) })
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, boards)
+}
+
+// apiCreateBoard creates new board with initial columns, the API user becomes its facilitator.
+// Body is optional, it sets template of the initial columns.
+func (a *app) apiCreateBoard(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Template string `json:"template"`
+	}
+	if r.ContentLength != 0 {
+		if err := readJSON(w, r, &input); err != nil {
+			a.apiError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if !board.ValidTemplate(input.Template) {
+		a.apiError(w, r, http.StatusBadRequest, fmt.Errorf("unknown template %q", input.Template))
+		return
+	}
+	user := r.Context().Value(apiUserKey).(*models.User)
+	b, err := a.manager.GetOrCreateBoard(r.Context(), uuid.New(), user, input.Template)
+	if err != nil {
+		a.apiError(w, r, http.StatusInternalServerError, fmt.Errorf("error a.manager.GetOrCreateBoard: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("board created via api", "id", b.ID, "user_id", user.ID)
+	writeJSON(w, http.StatusCreated, b)
+}
+
+// apiGetBoard returns board along with its columns and cards
+func (a *app) apiGetBoard(w http.ResponseWriter, r *http.Request) {
+	b, _, ok := a.apiBoard(w, r)
+	if !ok {
+		return
+	}
+	columns, cards, err := a.manager.Contents(r.Context(), b.ID)
+	if err != nil {
+		a.apiError(w, r, http.StatusInternalServerError, fmt.Errorf("error a.manager.Contents: %s", err.Error()))
+		return
+	}
+	if columns == nil {
+		columns = []models.Column{}
+	}
+	if cards == nil {
+		cards = []models.Card{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{"board": b, "columns": columns, "cards": cards})
+}
+
+// apiMessage returns handler that handles request body as message data of given type,
+// ID in the path (column or card) is set as message "id". It responds with the record created or updated.
+func (a *app) apiMessage(typ string, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]any{}
+		if r.Method != http.MethodDelete {
+			if err := readJSON(w, r, &data); err != nil {
+				a.apiError(w, r, http.StatusBadRequest, err)
+				return
+			}
+		}
+		if id := r.PathValue("id"); id != "" {
+			data["id"] = id
+		}
+		b, user, ok := a.apiBoard(w, r)
+		if !ok {
+			return
+		}
+
+		result, err := a.manager.Handle(r.Context(), b.ID, *user, typ, data)
+		if err != nil {
+			a.apiHandleError(w, r, err)
+			return
+		}
+		if status == http.StatusNoContent {
+			w.WriteHeader(status)
+			return
+		}
+		writeJSON(w, status, result)
+	}
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_restAPI(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL)
+	api := testBrowser(t, a)
+
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, uuid.New()))
+
+	// token is created by the session user and only returned once
+	code, created := postJSON(t, alice, http.MethodPost, testURL+"/profile/tokens", map[string]any{"name": "ci"})
+	require.Equal(t, http.StatusCreated, code)
+	token := created["token"].(string)
+	code, _ = postJSON(t, alice, http.MethodPost, testURL+"/profile/tokens", map[string]any{"name": "ci", "ttl": "9000h"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// token is required
+	code, _ = apiJSON(t, api, "", http.MethodPost, testURL+"/api/boards", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = apiJSON(t, api, token+"x", http.MethodPost, testURL+"/api/boards", nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// board created via API has initial columns, the token user is its facilitator
+	code, b := apiJSON(t, api, token, http.MethodPost, testURL+"/api/boards", nil)
+	require.Equal(t, http.StatusCreated, code)
+	boardID := uuid.MustParse(b["id"].(string))
+	assert.Equal(t, created["user_id"], b["facilitator_id"])
+	boardURL := fmt.Sprintf("%s/api/boards/%s", testURL, boardID)
+
+	code, contents := apiJSON(t, api, token, http.MethodGet, boardURL, nil)
+	require.Equal(t, http.StatusOK, code)
+	columns := contents["columns"].([]any)
+	require.NotEmpty(t, columns)
+	assert.Empty(t, contents["cards"])
+	col := columns[0].(map[string]any)
+
+	// cards
+	code, card := apiJSON(t, api, token, http.MethodPost, boardURL+"/cards", map[string]any{"name": "ship it", "column_id": col["id"]})
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "ship it", card["name"])
+	cardURL := fmt.Sprintf("%s/cards/%s", boardURL, card["id"])
+
+	code, card = apiJSON(t, api, token, http.MethodPost, cardURL+"/votes", map[string]any{"vote": 1})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), card["votes"])
+
+	code, card = apiJSON(t, api, token, http.MethodPatch, cardURL, map[string]any{"name": "shipped"})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "shipped", card["name"])
+	code, contents = apiJSON(t, api, token, http.MethodGet, boardURL, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, contents["cards"], 1)
+	assert.Equal(t, "shipped", contents["cards"].([]any)[0].(map[string]any)["name"])
+
+	code, _ = apiJSON(t, api, token, http.MethodDelete, cardURL, nil)
+	require.Equal(t, http.StatusNoContent, code)
+	code, contents = apiJSON(t, api, token, http.MethodGet, boardURL, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, contents["cards"])
+
+	// same validation as websocket messages
+	for _, input := range []struct {
+		method, url string
+		body        any
+		code        int
+	}{
+		{http.MethodPost, boardURL + "/cards", map[string]any{"name": "no column"}, http.StatusBadRequest},
+		{http.MethodPost, boardURL + "/cards", map[string]any{"name": "x", "column_id": uuid.NewString()}, http.StatusNotFound},
+		{http.MethodPost, cardURL + "/votes", map[string]any{"vote": 1}, http.StatusNotFound},
+		{http.MethodPost, boardURL + "/columns/" + col["id"].(string) + "/votes", map[string]any{}, http.StatusNotFound},
+		{http.MethodPatch, boardURL, map[string]any{"timer_warning": -1}, http.StatusBadRequest},
+		{http.MethodGet, fmt.Sprintf("%s/api/boards/%s", testURL, uuid.New()), nil, http.StatusNotFound},
+	} {
+		code, _ = apiJSON(t, api, token, input.method, input.url, input.body)
+		assert.Equal(t, input.code, code, input)
+	}
+
+	// board limits apply too
+	for i := len(columns); i < 6; i++ {
+		code, _ = apiJSON(t, api, token, http.MethodPost, boardURL+"/columns", map[string]any{"name": fmt.Sprint("col", i)})
+		require.Equal(t, http.StatusCreated, code)
+	}
+	code, _ = apiJSON(t, api, token, http.MethodPost, boardURL+"/columns", map[string]any{"name": "too many"})
+	assert.Equal(t, http.StatusConflict, code)
+
+	// private board can only be used by its facilitator
+	bob := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, bob, boardID))
+	code, created = postJSON(t, bob, http.MethodPost, testURL+"/profile/tokens", map[string]any{"name": "bob"})
+	require.Equal(t, http.StatusCreated, code)
+	bobToken := created["token"].(string)
+	code, _ = apiJSON(t, api, bobToken, http.MethodGet, boardURL, nil)
+	assert.Equal(t, http.StatusOK, code)
+	require.Equal(t, http.StatusOK, visit(t, alice, boardID))
+	code, _ = postJSON(t, alice, http.MethodPost, fmt.Sprintf("%s/b/%s/settings/access", testURL, boardID), map[string]any{"private": true})
+	require.Equal(t, http.StatusNoContent, code)
+	code, _ = apiJSON(t, api, bobToken, http.MethodGet, boardURL, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = apiJSON(t, api, token, http.MethodGet, boardURL, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// revoked token can't be used
+	resp, err := alice.Get(testURL + "/profile/tokens")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var tokens []map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tokens))
+	require.Len(t, tokens, 1)
+	assert.NotContains(t, tokens[0], "hash")
+	code, _ = postJSON(t, alice, http.MethodDelete, fmt.Sprintf("%s/profile/tokens/%s", testURL, tokens[0]["id"]), nil)
+	require.Equal(t, http.StatusNoContent, code)
+	code, _ = apiJSON(t, api, token, http.MethodGet, boardURL, nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func Test_boardLifecycle(t *testing.T) {
+	srv := natstest.Server(t)
+	var st *store.Store
+	instance := testInstance(t, srv, func(a *app, _ string) { st = a.store })
+	boardID := uuid.New()
+	boardURL := fmt.Sprintf("%s/api/boards/%s", instance.URL, boardID)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	alice := &http.Client{Jar: jar}
+	aliceWS := joinWith(t, alice, instance, boardID, "alice")
+	bobWS := join(t, instance, boardID, "bob")
+	code, created := postJSON(t, alice, http.MethodPost, instance.URL+"/profile/tokens", map[string]any{"name": "ci"})
+	require.Equal(t, http.StatusCreated, code)
+	token := created["token"].(string)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+	code, hook := postJSON(t, alice, http.MethodPost, fmt.Sprintf("%s/b/%s/webhooks", instance.URL, boardID), map[string]any{"url": receiver.URL, "events": []string{"card.created"}})
+	require.Equal(t, http.StatusCreated, code)
+	hookID := uuid.MustParse(hook["id"].(string))
+
+	// only facilitator locks the board
+	good := bobWS.waitForObject("columns", func(obj map[string]any) bool { return obj["name"] == "Good" })
+	bobWS.send("board.lock", map[string]any{"locked": true})
+	bobWS.waitFor("board.notification", func(m map[string]any) bool { return strings.Contains(m["data"].(string), "only facilitator") })
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/lock", map[string]any{"locked": "yes"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// nobody changes locked board, including its facilitator, and its running timer is paused
+	timerStatus := func(status string) func(map[string]any) bool {
+		return func(m map[string]any) bool { return m["data"].(map[string]any)["status"] == status }
+	}
+	aliceWS.send("timer.cmd", map[string]any{"cmd": "start", "value": "1m"})
+	bobWS.waitFor("timer.state", timerStatus("running"))
+	aliceWS.send("board.lock", map[string]any{"locked": true})
+	bobWS.waitForObject("board", func(obj map[string]any) bool { return obj["locked"] == true })
+	bobWS.waitFor("timer.state", timerStatus("paused"))
+	bobWS.send("card.new", map[string]any{"name": "late", "column_id": good["id"]})
+	bobWS.send("timer.cmd", map[string]any{"cmd": "start", "value": "1m"})
+	bobWS.waitFor("board.notification", func(m map[string]any) bool { return strings.Contains(m["data"].(string), "locked") })
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/cards", map[string]any{"name": "late", "column_id": good["id"]})
+	assert.Equal(t, http.StatusLocked, code)
+	_, found := bobWS.find("cards", func(m map[string]any) bool { return true })
+	assert.False(t, found)
+	_, found = bobWS.find("board.notification", func(m map[string]any) bool { return strings.Contains(m["data"].(string), "resumed") })
+	assert.False(t, found)
+
+	code, b := apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/lock", map[string]any{"locked": false})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, b["locked"])
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/cards", map[string]any{"name": "on time", "column_id": good["id"]})
+	require.Equal(t, http.StatusCreated, code)
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		deliveries, err := st.Webhooks.ListDeliveries(ctx, hookID, 10)
+		return err == nil && len(deliveries) == 1
+	}, convergeTimeout, 10*time.Millisecond)
+
+	// archived board is read-only and hidden from the list, but still viewable
+	listBoards := func(query string) []any {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, instance.URL+"/api/boards"+query, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var boards []any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&boards))
+		return boards
+	}
+	require.Len(t, listBoards(""), 1)
+
+	code, b = apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/archive", map[string]any{"archived": true})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, b["archived"])
+	bobWS.waitForObject("board", func(obj map[string]any) bool { return obj["archived"] == true })
+	assert.Empty(t, listBoards(""))
+	assert.Len(t, listBoards("?archived=true"), 1)
+	code, contents := apiJSON(t, http.DefaultClient, token, http.MethodGet, boardURL, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, contents["cards"], 1)
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodPost, boardURL+"/columns", map[string]any{"name": "more"})
+	assert.Equal(t, http.StatusLocked, code)
+
+	// deleting the board deletes all its records and closes the connections
+	ticket := wsTicket(alice, instance.URL, boardID)
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodDelete, boardURL, nil)
+	require.Equal(t, http.StatusNoContent, code)
+	for _, c := range []*testClient{aliceWS, bobWS} {
+		err := c.waitClosed()
+		assert.True(t, websocket.IsCloseError(err, 4004), err)
+	}
+
+	_, err = st.Boards.Get(ctx, boardID)
+	assert.ErrorIs(t, err, store.ErrDeleted)
+	columns, err := st.Columns.List(ctx, boardID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, columns)
+	cards, err := st.Cards.List(ctx, boardID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, cards)
+	clients, err := st.Clients.ListKeys(ctx, boardID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, clients)
+	participants, err := st.Participants.List(ctx, boardID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, participants)
+	hooks, err := st.Webhooks.List(ctx, boardID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, hooks)
+	deliveries, err := st.Webhooks.ListDeliveries(ctx, hookID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	assert.Empty(t, listBoards("?archived=true"))
+
+	// deleted board is gone, it's not created again by opening its link
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodDelete, boardURL, nil)
+	assert.Equal(t, http.StatusGone, code)
+	code, _ = apiJSON(t, http.DefaultClient, token, http.MethodGet, boardURL, nil)
+	assert.Equal(t, http.StatusGone, code)
+	for _, path := range []string{"", "/summary"} {
+		resp, err := alice.Get(fmt.Sprintf("%s/b/%s%s", instance.URL, boardID, path))
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusGone, resp.StatusCode, path)
+	}
+	_, resp, err := dialWithTicket(alice, instance, boardID, "alice", ticket)
+	assert.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+	_, err = st.Boards.Get(ctx, boardID)
+	assert.ErrorIs(t, err, store.ErrDeleted)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+)
+
+// session keys of login in progress
+const (
+	oidcStateKey    = "oidc_state"
+	oidcNonceKey    = "oidc_nonce"
+	oidcVerifierKey = "oidc_verifier"
+	oidcNextKey     = "oidc_next"
+)
+
+// loginURL returns URL to login then return to next
+func loginURL(next string) string {
+	return "/auth/login?next=" + url.QueryEscape(next)
+}
+
+// login redirects user to OIDC provider login page
+func (a *app) login(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		a.clientError(w, r, http.StatusNotFound, errors.New("login disabled"))
+		return
+	}
+
+	state, nonce, verifier := rand.Text(), rand.Text(), oauth2.GenerateVerifier()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	session.Values[oidcStateKey] = state
+	session.Values[oidcNonceKey] = nonce
+	session.Values[oidcVerifierKey] = verifier
+	session.Values[oidcNextKey] = safeRedirect(r.URL.Query().Get("next"))
+	if err := session.Save(r, w); err != nil {
+		a.serverError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, a.oidc.AuthCodeURL(state, nonce, verifier), http.StatusFound)
+}
+
+// loginCallback completes the login, the session user becomes the user of the identity
+func (a *app) loginCallback(w http.ResponseWriter, r *http.Request) {
+	if a.oidc == nil {
+		a.clientError(w, r, http.StatusNotFound, errors.New("login disabled"))
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	session, _ := a.session.Get(r, SESSION_NAME)
+	state, _ := session.Values[oidcStateKey].(string)
+	nonce, _ := session.Values[oidcNonceKey].(string)
+	verifier, _ := session.Values[oidcVerifierKey].(string)
+	next, _ := session.Values[oidcNextKey].(string)
+	if state == "" || q.Get("state") != state {
+		a.clientError(w, r, http.StatusBadRequest, errors.New("invalid login state"))
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("login failed: %s %s", e, q.Get("error_description")))
+		return
+	}
+
+	identity, err := a.oidc.Exchange(ctx, q.Get("code"), nonce, verifier)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("login failed: %s", err.Error()))
+		return
+	}
+
+	// name and avatar might have changed at the provider, so always update
+	user := identity.User()
+	if err := a.store.Users.Update(ctx, user); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Users.Update: %s", err.Error()))
+		return
+	}
+
+	for _, key := range []string{oidcStateKey, oidcNonceKey, oidcVerifierKey, oidcNextKey} {
+		delete(session.Values, key)
+	}
+	session.Values["user_id"] = user.ID
+	if err := session.Save(r, w); err != nil {
+		a.serverError(w, r, err)
+		return
+	}
+	a.requestLogger(r).Info("user logged in", "id", user.ID)
+	http.Redirect(w, r, safeRedirect(next), http.StatusSeeOther)
+}
+
+// logout removes user from the session, anonymous user is created on next visit.
+// It's a form post, so only the origins allowed to open websocket can log users out.
+func (a *app) logout(w http.ResponseWriter, r *http.Request) {
+	if !a.upgrader.CheckOrigin(r) {
+		a.clientError(w, r, http.StatusForbidden, errors.New("origin not allowed"))
+		return
+	}
+	session, _ := a.session.Get(r, SESSION_NAME)
+	delete(session.Values, "user_id")
+	if err := session.Save(r, w); err != nil {
+		a.serverError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, safeRedirect(r.FormValue("next")), http.StatusSeeOther)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/auth/authtest"
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_oidcLogin(t *testing.T) {
+	idp := authtest.NewIdP("goretro")
+	t.Cleanup(idp.Close)
+	a := testApp(t, natstest.Server(t), testURL, withOIDC(t, idp))
+	boardID := uuid.New()
+	boardURL := fmt.Sprintf("%s/b/%s", testURL, boardID)
+
+	// login goes through the provider and back to the board, alice creates it
+	alice := testBrowser(t, a)
+	idp.Login(map[string]any{"sub": "alice-id", "name": "Alice", "picture": "https://idp.example.com/alice.png"})
+	resp, err := alice.Get(fmt.Sprintf("%s/auth/login?next=/b/%s", testURL, boardID))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, boardURL, resp.Request.URL.String())
+
+	// name comes from the provider
+	user := profileOf(t, alice)
+	assert.Equal(t, "Alice", user.Name)
+	assert.True(t, user.Authenticated)
+	assert.Equal(t, "https://idp.example.com/alice.png", user.AvatarURL)
+
+	// anonymous users are allowed by default, only facilitator changes board settings
+	anon := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, anon, boardID))
+	ctx := context.Background()
+	_, err = a.manager.Handle(ctx, boardID, profileOf(t, anon), "board.update", map[string]any{"require_auth": true})
+	assert.ErrorIs(t, err, board.ErrNotFacilitator)
+	_, err = a.manager.Handle(ctx, boardID, user, "board.update", map[string]any{"require_auth": true})
+	require.NoError(t, err)
+
+	// anonymous user is sent to login page and can't connect
+	bob := testBrowser(t, a)
+	bob.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err = bob.Get(boardURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, resp.StatusCode)
+	assert.Equal(t, loginURL("/b/"+boardID.String()), resp.Header.Get("Location"))
+
+	resp, err = bob.Get(boardURL + "/ws/ticket")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// other sites can't log alice out
+	alice.CheckRedirect = bob.CheckRedirect
+	req, err := http.NewRequest(http.MethodPost, testURL+"/auth/logout", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://evil.example")
+	resp, err = alice.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, visit(t, alice, boardID))
+
+	// after logout, alice is anonymous again
+	resp, err = alice.PostForm(testURL+"/auth/logout", url.Values{"next": {"/b/" + boardID.String()}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, visit(t, alice, boardID))
+}
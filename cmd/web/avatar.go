@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/avatar"
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	// Maximum size of uploaded avatar image.
+	maxAvatarUploadSize = 2 << 20 // 2MB
+
+	// Maximum number of avatar uploads processed at once, decoded images take a lot of memory.
+	maxConcurrentAvatarUploads = 4
+
+	// Cache max-age of avatars, uploaded avatar URL changes on every upload.
+	avatarMaxAge = 24 * time.Hour
+)
+
+// avatarKey is the blob key of uploaded avatar of the user
+func avatarKey(userID uuid.UUID) string {
+	return fmt.Sprintf("avatars.%s", userID)
+}
+
+// avatar serves avatar generated from user ID, or uploaded avatar when its version is requested.
+// Generated avatar is served when uploaded one is gone e.g expired.
+func (a *app) avatar(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("user"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(avatarMaxAge.Seconds())))
+
+	blob, err := a.store.Blobs.Get(r.Context(), avatarKey(userID))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		a.serverError(w, r, fmt.Errorf("error a.store.Blobs.Get: %s", err.Error()))
+		return
+	}
+	if blob != nil && r.URL.Query().Has("v") {
+		w.Header().Set("Content-Type", blob.ContentType)
+		w.Write(blob.Data)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(avatar.Identicon(userID[:]))
+}
+
+// uploadAvatar resizes uploaded image and sets it as the session user avatar.
+// Image must be sent as request body with its content type, browsers don't send it cross-site without CORS preflight.
+func (a *app) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !strings.HasPrefix(mediaType, "image/") {
+		a.clientError(w, r, http.StatusUnsupportedMediaType, errors.New("content type must be image"))
+		return
+	}
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	if user.Authenticated {
+		a.clientError(w, r, http.StatusForbidden, board.ErrProfileManaged)
+		return
+	}
+
+	select {
+	case a.avatarUploads <- struct{}{}:
+		defer func() { <-a.avatarUploads }()
+	case <-ctx.Done():
+		a.clientError(w, r, http.StatusServiceUnavailable, fmt.Errorf("error waiting for avatar upload slot: %s", ctx.Err().Error()))
+		return
+	}
+	img, err := avatar.Resize(http.MaxBytesReader(w, r.Body, maxAvatarUploadSize))
+	if err != nil {
+		if errors.Is(err, avatar.ErrInvalidImage) {
+			a.clientErrorMessage(w, r, http.StatusBadRequest, err)
+			return
+		}
+		a.serverError(w, r, fmt.Errorf("error avatar.Resize: %s", err.Error()))
+		return
+	}
+	if err := a.store.Blobs.Put(ctx, avatarKey(user.ID), store.Blob{Data: img, ContentType: "image/png"}); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Blobs.Put: %s", err.Error()))
+		return
+	}
+
+	// version in URL so browsers don't show the cached previous avatar
+	url := fmt.Sprintf("/avatars/%s?v=%d", user.ID, time.Now().UnixMilli())
+	a.respondProfile(w, r, user.ID, board.Profile{AvatarURL: &url})
+}
+
+// deleteAvatar removes uploaded avatar of the session user, generated avatar is used instead
+func (a *app) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	if err := a.store.Blobs.Delete(ctx, avatarKey(user.ID)); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Blobs.Delete: %s", err.Error()))
+		return
+	}
+	generated := 0
+	a.respondProfile(w, r, user.ID, board.Profile{AvatarID: &generated})
+}
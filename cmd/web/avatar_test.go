@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/avatar"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_avatar(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL)
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, uuid.New()))
+
+	// new users get generated avatar
+	user := profileOf(t, alice)
+	assert.Equal(t, 0, user.AvatarID)
+
+	get := func(path string) (*http.Response, []byte) {
+		resp, err := testBrowser(t, a).Get(testURL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+	resp, body := get("/avatars/" + user.ID.String())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(body, []byte("<svg ")))
+
+	upload := func(contentType string, data []byte) (int, map[string]any) {
+		req, err := http.NewRequest(http.MethodPost, testURL+"/profile/avatar", bytes.NewReader(data))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		resp, err := alice.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var user map[string]any
+		json.NewDecoder(resp.Body).Decode(&user)
+		return resp.StatusCode, user
+	}
+
+	// uploaded avatar is resized
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 300, 200))))
+	code, _ := upload("text/plain", img.Bytes())
+	assert.Equal(t, http.StatusUnsupportedMediaType, code)
+	code, _ = upload("image/png", []byte("not an image"))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, uploaded := upload("image/png", img.Bytes())
+	require.Equal(t, http.StatusOK, code)
+	avatarURL := uploaded["avatar_url"].(string)
+	assert.Equal(t, avatarURL, profileOf(t, alice).AvatarURL)
+
+	resp, body = get(avatarURL)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	resized, err := png.Decode(bytes.NewReader(body))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, avatar.Size, avatar.Size), resized.Bounds())
+
+	// removing it goes back to generated avatar
+	code, removed := postJSON(t, alice, http.MethodDelete, testURL+"/profile/avatar", nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, removed["avatar_url"])
+	assert.Equal(t, float64(0), removed["avatar_id"])
+	resp, _ = get(avatarURL)
+	assert.Equal(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+}
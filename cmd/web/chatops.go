@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/chatops"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+// Maximum size of slash command request body.
+const maxSlashCommandSize = 8192
+
+// slashCommand handles Slack or Mattermost slash command (e.g `/retro new` or `/retro summary <board link>`).
+// It's only enabled when Slack signing secret or Mattermost token is configured.
+func (a *app) slashCommand(w http.ResponseWriter, r *http.Request) {
+	if a.config.slackSecret == "" && a.config.mattermostToken == "" {
+		a.clientError(w, r, http.StatusNotFound, errors.New("chat-ops not configured"))
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSlashCommandSize))
+	if err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if err := a.verifySlashCommand(r.Header, body, form); err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, err)
+		return
+	}
+
+	var resp chatops.Response
+	args := strings.Fields(form.Get("text"))
+	switch {
+	case len(args) > 0 && args[0] == "new" && len(args) <= 2:
+		var template string
+		if len(args) == 2 {
+			template = strings.ToLower(args[1])
+		}
+		if !board.ValidTemplate(template) {
+			resp = chatops.Error("Unknown template %q, available templates: %s", template, strings.Join(board.TemplateNames(), ", "))
+			break
+		}
+		resp = chatops.NewBoard(a.externalURL(r, newBoardPath(template)), template)
+	case len(args) == 2 && args[0] == "summary":
+		resp = a.slashSummary(r, args[1])
+	default:
+		resp = chatops.Help(form.Get("command"))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// verifySlashCommand verifies the request with Slack signature when it has one, otherwise with Mattermost token
+func (a *app) verifySlashCommand(header http.Header, body []byte, form url.Values) error {
+	if header.Get("X-Slack-Signature") != "" && a.config.slackSecret != "" {
+		return chatops.VerifySlack(a.config.slackSecret, header, body, time.Now())
+	}
+	if a.config.mattermostToken != "" {
+		return chatops.VerifyMattermost(a.config.mattermostToken, header, form)
+	}
+	return chatops.ErrUnverified
+}
+
+// slashSummary returns summary response of the board, private boards and boards requiring sign-in are not summarized to the channel
+func (a *app) slashSummary(r *http.Request, ref string) chatops.Response {
+	boardID, err := parseBoardRef(ref)
+	if err != nil {
+		return chatops.Error("%q is not a board link or ID", ref)
+	}
+	s, err := a.manager.Summary(r.Context(), boardID)
+	if errors.Is(err, store.ErrDeleted) {
+		return chatops.Error("Board was deleted")
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return chatops.Error("Board not found, boards are removed after 2 hours of inactivity")
+	}
+	if err != nil {
+		a.requestLogger(r).Error(fmt.Sprintf("error a.manager.Summary: %s", err.Error()), "type", "server-error")
+		return chatops.Error("Something went wrong, please try again later")
+	}
+	if s.Board.Private {
+		return chatops.Error("Board is private, its summary can't be shared")
+	}
+	if s.Board.RequireAuth {
+		return chatops.Error("Board requires sign-in, its summary can't be shared")
+	}
+	return chatops.Summary(a.externalURL(r, "/b/"+boardID.String()), s)
+}
+
+// parseBoardRef returns board ID of board link or ID.
+// Slack sends links as <url> or <url|label>.
+func parseBoardRef(ref string) (uuid.UUID, error) {
+	ref = strings.TrimSuffix(strings.TrimPrefix(ref, "<"), ">")
+	ref, _, _ = strings.Cut(ref, "|")
+	if u, err := url.Parse(ref); err == nil && u.Host != "" {
+		id, ok := strings.CutPrefix(u.Path, "/b/")
+		if !ok {
+			return uuid.Nil, errors.New("not a board link")
+		}
+		ref, _, _ = strings.Cut(id, "/")
+	}
+	return uuid.Parse(ref)
+}
+
+// externalURL returns absolute URL of the path, on the configured base URL or the URL of the request
+func (a *app) externalURL(r *http.Request, path string) string {
+	if a.config.baseURL != "" {
+		return a.config.baseURL + path
+	}
+	scheme := "http"
+	if r.TLS != nil || a.config.secure {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_slashCommand(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL, func(a *app, _ string) {
+		a.config.slackSecret = "slack-secret"
+		a.config.mattermostToken = "mattermost-token"
+	})
+	chat := testBrowser(t, a)
+	commandURL := testURL + "/chatops/command"
+
+	slack := func(text string) (int, map[string]any) {
+		t.Helper()
+		body := url.Values{"command": {"/retro"}, "text": {text}}.Encode()
+		ts := time.Now().Unix()
+		mac := hmac.New(sha256.New, []byte("slack-secret"))
+		fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+		req, err := http.NewRequest(http.MethodPost, commandURL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(ts, 10))
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := chat.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var out map[string]any
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	// unverified requests are refused
+	resp, err := chat.PostForm(commandURL, url.Values{"text": {"new"}, "token": {"wrong"}})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	code, out := slack("")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ephemeral", out["response_type"])
+	assert.Contains(t, out["text"], "/retro new [template]")
+
+	code, out = slack("new nope")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ephemeral", out["response_type"])
+	assert.Contains(t, out["text"], "Unknown template")
+
+	code, out = slack("new sailboat")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "in_channel", out["response_type"])
+	text := out["text"].(string)
+	link := text[strings.Index(text, testURL):]
+	assert.Contains(t, link, "?template=sailboat")
+
+	// the link creates board with columns of the template
+	alice := testBrowser(t, a)
+	resp, err = alice.Get(link)
+	require.NoError(t, err)
+	resp.Body.Close()
+	boardID := uuid.MustParse(strings.TrimPrefix(resp.Request.URL.Path, "/b/"))
+	ctx := context.Background()
+	user := profileOf(t, alice)
+	newCard := func(name, column string) string {
+		t.Helper()
+		card, err := a.manager.Handle(ctx, boardID, user, "card.new", map[string]any{"name": name, "column_id": boardColumn(t, a, boardID, column).ID.String()})
+		require.NoError(t, err)
+		return card.(*models.Card).ID.String()
+	}
+	cardID := newCard("pairing", "Wind")
+	newCard("fix ci", "Action items")
+	// data as decoded from JSON message
+	_, err = a.manager.Handle(ctx, boardID, user, "card.vote", map[string]any{"id": cardID, "vote": float64(1)})
+	require.NoError(t, err)
+
+	// summary accepts board link as Slack sends it, Mattermost token is accepted too
+	code, out = slack(fmt.Sprintf("summary <%s/b/%s>", testURL, boardID))
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "in_channel", out["response_type"])
+	assert.Contains(t, out["text"], "• pairing (Wind, 1 vote)")
+	assert.Contains(t, out["text"], "• fix ci")
+
+	// board requiring sign-in isn't summarized to the channel
+	_, err = a.store.Boards.Update(ctx, boardID, func(b *models.Board) error {
+		b.RequireAuth = true
+		return nil
+	})
+	require.NoError(t, err)
+	code, out = slack("summary " + boardID.String())
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ephemeral", out["response_type"])
+	assert.Contains(t, out["text"], "requires sign-in")
+	assert.NotContains(t, out["text"], "pairing")
+
+	resp, err = chat.PostForm(commandURL, url.Values{"text": {"summary " + uuid.NewString()}, "token": {"mattermost-token"}})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+	assert.Equal(t, "ephemeral", out["response_type"])
+	assert.Contains(t, out["text"], "Board not found")
+}
@@ -3,7 +3,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/auth"
+	"github.com/ekaputra07/go-retro/internal/issues"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/webhook"
 )
 
 var (
@@ -14,24 +24,101 @@ var (
 
 // config stores all configurable values
 type config struct {
-	port           int
-	secret         string
-	initialColumns string
-	natsUrl        string
-	natsCreds      string
-	secure         bool
+	port            int
+	metricsPort     int
+	secret          string
+	initialColumns  string
+	natsUrl         string
+	natsCreds       string
+	natsEmbedded    bool
+	natsServer      natsutil.ServerConfig
+	secure          bool
+	otlpEndpoint    string
+	otlpInsecure    bool
+	traceSampling   float64
+	logFormat       string
+	logLevel        slog.Level
+	trustedProxies  []netip.Prefix
+	allowedOrigins  []string
+	shutdownTimeout time.Duration
+	oidc            auth.Config
+	webhooks        webhook.Config
+	baseURL         string
+	slackSecret     string
+	mattermostToken string
+	githubURL       string
+	githubRepo      string
+	githubToken     string
 }
 
 func parseConfig() config {
 	conf := config{}
 	flag.IntVar(&conf.port, "port", 8080, "Port to listen")
+	flag.IntVar(&conf.metricsPort, "metrics-port", 0, "Port to expose Prometheus metrics on, separate from the app port so they're not public, disabled when 0")
 	flag.StringVar(&conf.secret, "secret", os.Getenv("GORETRO_SESSION_SECRET"), "Session secret")
 	flag.StringVar(&conf.initialColumns, "initialColumns", "Good,Bad,Questions,Emoji", "Initial board columns")
 	flag.StringVar(&conf.natsUrl, "nats-url", os.Getenv("GORETRO_NATS_URL"), "NATS Url")
 	flag.StringVar(&conf.natsCreds, "nats-cred", os.Getenv("GORETRO_NATS_CREDS"), "Based64 encoded NATS Credentials")
+	flag.BoolVar(&conf.natsEmbedded, "nats-embedded", os.Getenv("GORETRO_NATS_EMBEDDED") == "true", "Run embedded NATS server instead of connecting to external one")
+	flag.StringVar(&conf.natsServer.DataDir, "nats-data-dir", getEnv("GORETRO_NATS_DATA_DIR", "data/nats"), "Embedded NATS server data directory")
+	flag.StringVar(&conf.natsServer.Name, "nats-server-name", getEnv("GORETRO_NATS_SERVER_NAME", hostname()), "Embedded NATS server name, must be unique within the cluster")
+	flag.StringVar(&conf.natsServer.ClusterName, "nats-cluster-name", getEnv("GORETRO_NATS_CLUSTER_NAME", "goretro"), "Embedded NATS cluster name")
+	flag.StringVar(&conf.natsServer.ClusterListen, "nats-cluster-listen", os.Getenv("GORETRO_NATS_CLUSTER_LISTEN"), "Address (host:port) embedded NATS server listens for cluster routes, clustering disabled when empty")
+	routes := flag.String("nats-routes", os.Getenv("GORETRO_NATS_ROUTES"), "Comma separated route URLs of other embedded NATS servers in the cluster (e.g nats://10.0.0.2:6222)")
 	flag.BoolVar(&conf.secure, "secure", false, "Secure cookie by default")
+	flag.StringVar(&conf.otlpEndpoint, "otlp-endpoint", os.Getenv("GORETRO_OTLP_ENDPOINT"), "OTLP HTTP endpoint (host:port) to export traces, tracing disabled when empty")
+	flag.BoolVar(&conf.otlpInsecure, "otlp-insecure", false, "Export traces to OTLP endpoint without TLS")
+	flag.Float64Var(&conf.traceSampling, "trace-sampling", 1.0, "Ratio of traces to sample (0.0 - 1.0)")
+	flag.StringVar(&conf.logFormat, "log-format", getEnv("GORETRO_LOG_FORMAT", "text"), "Log output format (text or json)")
+	flag.TextVar(&conf.logLevel, "log-level", slog.LevelInfo, "Minimum log level (debug, info, warn or error)")
+	proxies := flag.String("trusted-proxies", os.Getenv("GORETRO_TRUSTED_PROXIES"), "Comma separated IPs or CIDRs of proxies trusted to set X-Forwarded-For")
+	origins := flag.String("allowed-origins", os.Getenv("GORETRO_ALLOWED_ORIGINS"), "Comma separated origins (e.g https://retro.example.com) allowed to open websocket in addition to the app's own origin")
+	flag.StringVar(&conf.oidc.Issuer, "oidc-issuer", os.Getenv("GORETRO_OIDC_ISSUER"), "OIDC provider issuer URL, login disabled when empty")
+	flag.StringVar(&conf.oidc.ClientID, "oidc-client-id", os.Getenv("GORETRO_OIDC_CLIENT_ID"), "OIDC client ID")
+	flag.StringVar(&conf.oidc.ClientSecret, "oidc-client-secret", os.Getenv("GORETRO_OIDC_CLIENT_SECRET"), "OIDC client secret")
+	flag.StringVar(&conf.oidc.RedirectURL, "oidc-redirect-url", os.Getenv("GORETRO_OIDC_REDIRECT_URL"), "OIDC callback URL (e.g https://retro.example.com/auth/callback)")
+	scopes := flag.String("oidc-scopes", getEnv("GORETRO_OIDC_SCOPES", "profile,email"), "Comma separated OIDC scopes to request in addition to openid")
+	webhookURLs := flag.String("webhook-urls", os.Getenv("GORETRO_WEBHOOK_URLS"), "Comma separated URLs of webhooks receiving events of all boards")
+	flag.StringVar(&conf.webhooks.Secret, "webhook-secret", os.Getenv("GORETRO_WEBHOOK_SECRET"), "Secret to sign requests to webhooks of all boards")
+	webhookEvents := flag.String("webhook-events", os.Getenv("GORETRO_WEBHOOK_EVENTS"), "Comma separated events sent to webhooks of all boards, all events when empty")
+	flag.BoolVar(&conf.webhooks.AllowPrivate, "webhook-allow-private", os.Getenv("GORETRO_WEBHOOK_ALLOW_PRIVATE") == "true", "Allow board webhooks to private network addresses")
+	baseURL := flag.String("base-url", os.Getenv("GORETRO_BASE_URL"), "Public URL (e.g https://retro.example.com) of links sent to chat, URL of the request when empty")
+	flag.StringVar(&conf.slackSecret, "slack-signing-secret", os.Getenv("GORETRO_SLACK_SIGNING_SECRET"), "Slack app signing secret to verify slash command requests")
+	flag.StringVar(&conf.mattermostToken, "mattermost-token", os.Getenv("GORETRO_MATTERMOST_TOKEN"), "Mattermost slash command token to verify its requests")
+	flag.StringVar(&conf.githubRepo, "github-repo", os.Getenv("GORETRO_GITHUB_REPO"), "GitHub repository (owner/name) facilitators create issues of cards in, disabled when empty")
+	flag.StringVar(&conf.githubToken, "github-token", os.Getenv("GORETRO_GITHUB_TOKEN"), "GitHub token allowed to create issues in the repository")
+	flag.StringVar(&conf.githubURL, "github-api-url", getEnv("GORETRO_GITHUB_API_URL", issues.DefaultGitHubURL), "GitHub API URL, e.g https://github.example.com/api/v3 for GitHub Enterprise Server")
+	flag.DurationVar(&conf.shutdownTimeout, "shutdown-timeout", 25*time.Second, "Time to wait for requests and websocket clients to finish on shutdown")
 	flag.Parse()
 
+	if conf.logFormat != "text" && conf.logFormat != "json" {
+		fmt.Printf("Invalid log format %q, must be `text` or `json`.\n", conf.logFormat)
+		os.Exit(1)
+	}
+
+	trusted, err := parseTrustedProxies(*proxies)
+	if err != nil {
+		fmt.Printf("Invalid trusted proxies: %s\n", err.Error())
+		os.Exit(1)
+	}
+	conf.trustedProxies = trusted
+	allowed, err := parseOrigins(*origins)
+	if err != nil {
+		fmt.Printf("Invalid allowed origins: %s\n", err.Error())
+		os.Exit(1)
+	}
+	conf.allowedOrigins = allowed
+	base, err := parseOrigins(*baseURL)
+	if err != nil || len(base) > 1 {
+		fmt.Printf("Invalid base URL %q, must be scheme://host[:port]\n", *baseURL)
+		os.Exit(1)
+	}
+	if len(base) == 1 {
+		conf.baseURL = base[0]
+	}
+	conf.natsServer.Routes = splitList(*routes)
+	conf.oidc.Scopes = splitList(*scopes)
+
 	// make sure secret is not empty
 	if conf.secret == "" {
 		fmt.Println(
@@ -41,5 +128,93 @@ func parseConfig() config {
 		)
 		os.Exit(1)
 	}
+
+	conf.webhooks.URLs = splitList(*webhookURLs)
+	conf.webhooks.Events = splitList(*webhookEvents)
+	for _, u := range conf.webhooks.URLs {
+		if err := webhook.ValidateURL(u); err != nil {
+			fmt.Printf("Invalid webhook URL %q: %s\n", u, err.Error())
+			os.Exit(1)
+		}
+	}
+	if err := webhook.ValidateEvents(conf.webhooks.Events); err != nil {
+		fmt.Printf("Invalid webhook events: %s\n", err.Error())
+		os.Exit(1)
+	}
+	if len(conf.webhooks.URLs) > 0 && conf.webhooks.Secret == "" {
+		fmt.Println("Webhook secret is required when webhook URLs are set.")
+		os.Exit(1)
+	}
+
+	if conf.oidc.Enabled() && (conf.oidc.ClientID == "" || conf.oidc.RedirectURL == "") {
+		fmt.Println("OIDC client ID and redirect URL are required when OIDC issuer is set.")
+		os.Exit(1)
+	}
 	return conf
 }
+
+// getEnv returns value of environment variable or fallback when it's empty
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// hostname returns host name or empty string when it's unknown
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// splitList splits comma separated values, empty values are skipped
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseOrigins parses comma separated origins, they're normalized to lowercase scheme://host[:port]
+func parseOrigins(s string) ([]string, error) {
+	var origins []string
+	for _, o := range splitList(s) {
+		u, err := url.Parse(o)
+		if err != nil {
+			return nil, err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return nil, fmt.Errorf("invalid origin %q, must be scheme://host[:port]", o)
+		}
+		origins = append(origins, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+	return origins, nil
+}
+
+// parseTrustedProxies parses comma separated IPs or CIDRs
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.Contains(p, "/") {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -1,98 +1,147 @@
 package main
 
 import (
-	"context"
 	"encoding/gob"
 	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/auth"
 	"github.com/ekaputra07/go-retro/internal/board"
 	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"github.com/gorilla/sessions"
 )
 
-const (
-	SESSION_NAME = "goretro_session"
-	// avatarsCount is the total number of avatars available to choose from.
-	// see: web/public/avatars
-	AVATARS_COUNT = 12
-)
+const SESSION_NAME = "goretro_session"
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin:     func(r *http.Request) bool { return true },
-}
+// wsTicketTTL is how long websocket ticket can be used to connect, the UI gets new one on reconnect.
+// It must be shorter than store.TicketTTL, used tickets are remembered only that long.
+const wsTicketTTL = 2 * time.Minute
 
 func init() {
 	gob.Register(uuid.UUID{})
+	gob.Register([]uuid.UUID{})
+}
+
+// live reports whether the app is alive, it stays ok while NATS connection is being re-established
+// as restarting the app won't help.
+func (a *app) live(w http.ResponseWriter, r *http.Request) {
+	if a.manager.Healthy() && !a.shuttingDown.Load() {
+		fmt.Fprint(w, "ok")
+	} else {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+	}
 }
 
-func (a *app) health(w http.ResponseWriter, r *http.Request) {
-	if a.manager.Healthy() {
+// ready reports whether the app can serve requests, it's not ready while NATS is disconnected.
+func (a *app) ready(w http.ResponseWriter, r *http.Request) {
+	if a.manager.Healthy() && !a.shuttingDown.Load() && a.nats.Connected() {
 		fmt.Fprint(w, "ok")
 	} else {
 		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
 	}
 }
 
+// generateBoardID redirects to new board, with columns of ?template= when given
 func (a *app) generateBoardID(w http.ResponseWriter, r *http.Request) {
-	http.Redirect(w, r, fmt.Sprintf("/b/%s", uuid.New()), http.StatusSeeOther)
+	http.Redirect(w, r, newBoardPath(r.URL.Query().Get("template")), http.StatusSeeOther)
 }
 
-func (a *app) board(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+// newBoardPath returns path of new board, its columns are of the template when it's valid
+func newBoardPath(template string) string {
+	path := fmt.Sprintf("/b/%s", uuid.New())
+	if template != "" && board.ValidTemplate(template) {
+		path += "?template=" + url.QueryEscape(template)
+	}
+	return path
+}
 
-	session, _ := a.session.Get(r, SESSION_NAME)
-	createUser := false
+func (a *app) board(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	// 1. create new user if:
-	// - session is newdb
+	// 1. create new (anonymous) user if:
+	// - session has no user (new session or logged out)
 	// - user_id in session no longer exists
-	if session.IsNew {
-		createUser = true
-	} else {
-		userID := session.Values["user_id"].(uuid.UUID)
-		if _, err := a.store.Users.Get(ctx, userID); err != nil {
-			createUser = true
-		}
-	}
-
-	if createUser {
-		avatarID := rand.Intn(AVATARS_COUNT-1) + 1
-		user := models.NewUser(avatarID)
-		err := a.store.Users.Create(ctx, user)
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		// avatar generated from user ID by default, so avatars on a board are unlikely to be the same
+		newUser := models.NewUser(0)
+		err := a.store.Users.Create(ctx, newUser)
 		if err != nil {
 			a.serverError(w, r, err)
 			return
 		}
 
-		session.Values["user_id"] = user.ID
+		session.Values["user_id"] = newUser.ID
 		if err := session.Save(r, w); err != nil {
 			a.serverError(w, r, err)
 			return
 		}
-		a.logger.Info("new user created", "id", user.ID)
+		a.requestLogger(r).Info("new user created", "id", newUser.ID)
+		user = &newUser
 	}
 
 	// 2. check if board record exist, if not then create
 	boardID := uuid.MustParse(r.PathValue("board"))
-	_, err := a.manager.GetOrCreateBoard(ctx, boardID)
+	b, err := a.manager.GetOrCreateBoard(ctx, boardID, user, r.URL.Query().Get("template"))
+	if errors.Is(err, store.ErrDeleted) {
+		a.clientError(w, r, http.StatusGone, err)
+		return
+	}
 	if err != nil {
 		a.serverError(w, r, fmt.Errorf("error a.manager.GetOrCreateBoard: %s", err.Error()))
 		return
 	}
+	if b.RequireAuth && !user.Authenticated {
+		if a.oidc != nil {
+			http.Redirect(w, r, loginURL(r.URL.Path), http.StatusSeeOther)
+			return
+		}
+		a.clientError(w, r, http.StatusForbidden, errors.New("board requires authenticated user"))
+		return
+	}
+
+	// 3. private board can only be joined with invite link or passcode, view link makes the user spectator of any board
+	if token := r.URL.Query().Get("invite"); token != "" {
+		if err := a.acceptInvite(ctx, session, b, user, token); err != nil {
+			a.requestLogger(r).Info("invite rejected", "id", boardID, "err", err)
+		} else {
+			if err := session.Save(r, w); err != nil {
+				a.serverError(w, r, err)
+				return
+			}
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return
+		}
+	}
+	spectator := isSpectator(session, b, user)
+	if !canAccess(session, b, user) && !spectator {
+		data, err := newTemplateData(a.config, templateData{User: user, AccessRequired: true})
+		if err != nil {
+			a.serverError(w, r, fmt.Errorf("error newTemplateData: %s", err.Error()))
+			return
+		}
+		a.render(w, r, http.StatusForbidden, data)
+		return
+	}
 
-	// 3. get board timer state (called just to start the timer in case not yet started)
-	isNew := a.manager.StartTimer(boardID)
+	// 4. get board timer state (called just to start the timer in case not yet started)
+	isNew := a.manager.StartTimer(ctx, boardID)
 	if isNew {
-		a.logger.Info("new timer started", "id", boardID)
+		a.requestLogger(r).Info("new timer started", "id", boardID)
 	}
 
-	data, err := newTemplateData(a.config)
+	data, err := newTemplateData(a.config, templateData{
+		User:         user,
+		WSTicket:     a.wsTicket(user, boardID),
+		IssueTracker: a.issueTrackerName(),
+		Spectator:    spectator,
+	})
 	if err != nil {
 		a.serverError(w, r, fmt.Errorf("error newTemplateData: %s", err.Error()))
 		return
@@ -100,50 +149,133 @@ func (a *app) board(w http.ResponseWriter, r *http.Request) {
 	a.render(w, r, http.StatusOK, data)
 }
 
+// wsTicket returns ticket for the user to connect to the board websocket
+func (a *app) wsTicket(user *models.User, boardID uuid.UUID) string {
+	return auth.SignTicket(a.config.secret, auth.Ticket{ID: uuid.New(), UserID: user.ID, BoardID: boardID, ExpiresAt: time.Now().Add(wsTicketTTL)})
+}
+
+// checkBoardAccess checks whether user can join the board, nil board is a new board anyone can join
+func checkBoardAccess(session *sessions.Session, b *models.Board, user *models.User) error {
+	if b == nil {
+		return nil
+	}
+	if b.RequireAuth && !user.Authenticated {
+		return errors.New("board requires authenticated user")
+	}
+	if !canAccess(session, b, user) && !isSpectator(session, b, user) {
+		return errors.New("private board access required")
+	}
+	return nil
+}
+
+// websocketTicket gives new websocket ticket to the board page, e.g for reconnecting once the ticket it got expired.
+// Other sites can't read the response as it's not allowed by CORS.
+func (a *app) websocketTicket(w http.ResponseWriter, r *http.Request) {
+	b, session, user, ok := a.requestBoard(w, r)
+	if !ok {
+		return
+	}
+	if err := checkBoardAccess(session, b, user); err != nil {
+		a.clientError(w, r, http.StatusForbidden, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]string{"ticket": a.wsTicket(user, b.ID)})
+}
+
 func (a *app) websocket(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
+
+	// tracked before upgrade so graceful shutdown will wait for it
+	a.clients.Add(1)
+	defer a.clients.Done()
 
 	// validate session (make sure user is present) before upgrading the connection
 	// TODO: Move this check to a middleware?
 	session, _ := a.session.Get(r, SESSION_NAME)
-	userID, ok := session.Values["user_id"]
-	if !ok {
-		a.clientError(w, r, http.StatusUnauthorized, errors.New("session missing user_id"))
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
 		return
 	}
 
-	user, err := a.store.Users.Get(ctx, userID.(uuid.UUID))
+	// ticket proves the connection is opened by the board page, not by other site using the session cookie
+	boardID := uuid.MustParse(r.PathValue("board"))
+	ticket, err := auth.VerifyTicket(a.config.secret, r.URL.Query().Get("t"), time.Now())
 	if err != nil {
-		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.store.Users.Get: %s", err.Error()))
+		a.clientError(w, r, http.StatusForbidden, fmt.Errorf("error auth.VerifyTicket: %s", err.Error()))
+		return
+	}
+	if ticket.UserID != user.ID || ticket.BoardID != boardID {
+		a.clientError(w, r, http.StatusForbidden, errors.New("ticket of other user or board"))
 		return
 	}
 
-	// all good, allow connection
-	boardID := r.PathValue("board")
-	username := r.URL.Query().Get("u")
+	b, err := a.store.Boards.Get(ctx, boardID)
+	if errors.Is(err, store.ErrDeleted) {
+		a.clientError(w, r, http.StatusGone, err)
+		return
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		a.serverError(w, r, fmt.Errorf("error a.store.Boards.Get: %s", err.Error()))
+		return
+	}
+	if err := checkBoardAccess(session, b, user); err != nil {
+		a.clientError(w, r, http.StatusForbidden, err)
+		return
+	}
+
+	// origin checked before the ticket is used up, so other site can't burn it
+	if !a.upgrader.CheckOrigin(r) {
+		a.clientError(w, r, http.StatusForbidden, errors.New("origin not allowed"))
+		return
+	}
+	err = a.store.Tickets.Use(ctx, ticket.ID)
+	if errors.Is(err, store.ErrTicketUsed) {
+		a.clientError(w, r, http.StatusForbidden, err)
+		return
+	}
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tickets.Use: %s", err.Error()))
+		return
+	}
 
-	// update name if different
-	if user.Name != username {
-		user.Name = username
-		if err = a.store.Users.Update(ctx, *user); err != nil {
-			a.serverError(w, r, fmt.Errorf("error a.store.Users.Update: %s", err.Error()))
+	// all good, allow connection.
+	// update name if given and different, authenticated user's name comes from the provider
+	if username := r.URL.Query().Get("u"); username != "" && !user.Authenticated && user.Name != username {
+		user, err = board.UpdateProfile(ctx, a.store, a.nats.Conn, user.ID, board.Profile{Name: &username})
+		if errors.Is(err, board.ErrInvalidProfile) {
+			a.clientError(w, r, http.StatusBadRequest, err)
 			return
 		}
+		if err != nil {
+			a.serverError(w, r, fmt.Errorf("error board.UpdateProfile: %s", err.Error()))
+			return
+		}
+	}
+	if user.Name == "" {
+		a.clientError(w, r, http.StatusBadRequest, errors.New("user name is required"))
+		return
 	}
 
 	// upgrade to websocket conn
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := a.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		a.serverError(w, r, fmt.Errorf("error upgrader.Upgrade: %s", err.Error()))
+		// upgrader already replied with error status e.g when origin not allowed
+		a.requestLogger(r).Warn("websocket upgrade failed", "err", err)
 		return
 	}
 	defer conn.Close()
 
 	// create client and start
-	client, err := board.NewClient(ctx, conn, user, a.logger, a.store, a.nats, uuid.MustParse(boardID))
+	spectator := b != nil && isSpectator(session, b, user)
+	client, err := board.NewClient(ctx, conn, user, a.requestLogger(r), a.store, a.nats, boardID, spectator)
 	if err != nil {
 		a.serverError(w, r, fmt.Errorf("error board.NewClient: %s", err.Error()))
 		return
 	}
-	client.Start()
+
+	// timer might have exited while board was idle, now that client is present it will keep running
+	a.manager.StartTimer(ctx, client.BoardID)
+	client.Start(ctx)
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_websocketHandshake(t *testing.T) {
+	srv := natstest.Server(t)
+	instance := testInstance(t, srv, func(a *app, _ string) {
+		a.upgrader.CheckOrigin = checkOrigin([]string{"https://retro.example.com"})
+	})
+	boardID := uuid.New()
+	newClient := func() *http.Client {
+		jar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+		return &http.Client{Jar: jar}
+	}
+	alice := newClient()
+	joinWith(t, alice, instance, boardID, "alice")
+	ticket := wsTicket(alice, instance.URL, boardID)
+	require.NotEmpty(t, ticket)
+
+	expectStatus := func(status int) func(*websocket.Conn, *http.Response, error) {
+		return func(conn *websocket.Conn, resp *http.Response, err error) {
+			t.Helper()
+			if conn != nil {
+				conn.Close()
+			}
+			if status == http.StatusSwitchingProtocols {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			require.NotNil(t, resp)
+			assert.Equal(t, status, resp.StatusCode)
+		}
+	}
+
+	// session cookie alone is not enough, e.g when other site opens the websocket
+	expectStatus(http.StatusForbidden)(dialWithTicket(alice, instance, boardID, "alice", ""))
+	expectStatus(http.StatusForbidden)(dialWithTicket(alice, instance, boardID, "alice", "invalid"))
+
+	// ticket is bound to the board and the user
+	expectStatus(http.StatusForbidden)(dialWithTicket(alice, instance, uuid.New(), "alice", ticket))
+	bob := newClient()
+	joinWith(t, bob, instance, boardID, "bob")
+	expectStatus(http.StatusForbidden)(dialWithTicket(bob, instance, boardID, "bob", ticket))
+
+	// only own and allowed origins
+	expectStatus(http.StatusForbidden)(dialWithTicket(alice, instance, boardID, "alice", ticket, http.Header{"Origin": {"https://evil.example.com"}}))
+	expectStatus(http.StatusSwitchingProtocols)(dialWithTicket(alice, instance, boardID, "alice", ticket, http.Header{"Origin": {instance.URL}}))
+	ticket = wsTicket(alice, instance.URL, boardID)
+	expectStatus(http.StatusSwitchingProtocols)(dialWithTicket(alice, instance, boardID, "alice", ticket, http.Header{"Origin": {"https://retro.example.com"}}))
+
+	// ticket can be used only once
+	expectStatus(http.StatusForbidden)(dialWithTicket(alice, instance, boardID, "alice", ticket, http.Header{"Origin": {instance.URL}}))
+}
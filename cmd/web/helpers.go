@@ -1,16 +1,167 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
 	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/sessions"
+)
+
+type contextKey string
+
+const (
+	requestIDKey    contextKey = "request_id"
+	requestIDHeader            = "X-Request-ID"
 )
 
 func (a *app) serverError(w http.ResponseWriter, r *http.Request, err error) {
-	a.logger.Error(err.Error(), "type", "server-error", "method", r.Method, "uri", r.URL.RequestURI(), "trace", string(debug.Stack()))
+	a.requestLogger(r).Error(err.Error(), "type", "server-error", "method", r.Method, "uri", logURI(r.URL), "trace", string(debug.Stack()))
 	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
 
 func (a *app) clientError(w http.ResponseWriter, r *http.Request, code int, err error) {
-	a.logger.Error(err.Error(), "type", "client-error", "method", r.Method, "uri", r.URL.RequestURI())
+	a.requestLogger(r).Error(err.Error(), "type", "client-error", "method", r.Method, "uri", logURI(r.URL))
 	http.Error(w, http.StatusText(code), code)
 }
+
+// clientErrorMessage is clientError that responds with the error message, for errors the UI shows to the user
+func (a *app) clientErrorMessage(w http.ResponseWriter, r *http.Request, code int, err error) {
+	a.requestLogger(r).Error(err.Error(), "type", "client-error", "method", r.Method, "uri", logURI(r.URL))
+	http.Error(w, err.Error(), code)
+}
+
+// secretQueryParams are query parameters carrying secrets: invite and view tokens, websocket ticket and OIDC code
+var secretQueryParams = []string{"invite", "t", "code", "state"}
+
+// logURI returns request URI to be logged, values of secretQueryParams are redacted
+func logURI(u *url.URL) string {
+	query := u.Query()
+	redacted := false
+	for _, p := range secretQueryParams {
+		if query.Has(p) {
+			query.Set(p, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u.RequestURI()
+	}
+	return u.EscapedPath() + "?" + query.Encode()
+}
+
+// requestLogger returns logger with request ID attribute
+func (a *app) requestLogger(r *http.Request) *slog.Logger {
+	if id, ok := r.Context().Value(requestIDKey).(string); ok {
+		return a.logger.With("request_id", id)
+	}
+	return a.logger
+}
+
+// safeRedirect returns next when it's a path on this site, otherwise "/".
+// It prevents open redirect e.g to "//evil.com" which browsers treat as other host.
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// checkOrigin returns websocket origin check, it allows the app's own origin and the allowed origins.
+// Requests without Origin header are allowed as they're not from browsers, they still need a ticket from the board page.
+func checkOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		if strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return slices.Contains(allowed, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+}
+
+// sessionUser returns user stored in the session
+func (a *app) sessionUser(ctx context.Context, session *sessions.Session) (*models.User, error) {
+	userID, ok := session.Values["user_id"].(uuid.UUID)
+	if !ok {
+		return nil, errors.New("session missing user_id")
+	}
+	return a.store.Users.Get(ctx, userID)
+}
+
+// newLogger creates logger with given format (text or json) and minimum level
+func newLogger(format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, opts))
+}
+
+// validRequestID checks whether request ID from header is safe to use
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// clientIP returns IP address of the client.
+// X-Forwarded-For header only considered when request comes from trusted proxy,
+// in that case the right-most address that is not a trusted proxy is returned.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	remote, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	ip := remote.Addr().Unmap()
+	if !isTrusted(ip, trusted) {
+		return ip.String()
+	}
+
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// can't go further than malformed address
+			break
+		}
+		ip = hop.Unmap()
+		if !isTrusted(ip, trusted) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
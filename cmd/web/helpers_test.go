@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies("10.0.0.0/8, 192.168.1.1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"no proxy", "1.2.3.4:1234", nil, "1.2.3.4"},
+		{"untrusted remote ignores header", "1.2.3.4:1234", []string{"5.6.7.8"}, "1.2.3.4"},
+		{"trusted remote", "10.1.2.3:1234", []string{"5.6.7.8"}, "5.6.7.8"},
+		{"skip trusted hops", "10.1.2.3:1234", []string{"5.6.7.8, 9.9.9.9, 192.168.1.1"}, "9.9.9.9"},
+		{"multiple headers", "10.1.2.3:1234", []string{"5.6.7.8", "9.9.9.9"}, "9.9.9.9"},
+		{"malformed hop", "10.1.2.3:1234", []string{"5.6.7.8, garbage"}, "10.1.2.3"},
+		{"all trusted", "10.1.2.3:1234", []string{"10.0.0.1"}, "10.0.0.1"},
+		{"ipv6", "[::1]:1234", nil, "::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, h := range tt.xff {
+				r.Header.Add("X-Forwarded-For", h)
+			}
+			assert.Equal(t, tt.want, clientIP(r, trusted))
+		})
+	}
+}
+
+func Test_parseTrustedProxies(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		p, err := parseTrustedProxies("")
+		assert.NoError(t, err)
+		assert.Empty(t, p)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseTrustedProxies("10.0.0.0/8,nope")
+		assert.Error(t, err)
+	})
+}
+
+func Test_validRequestID(t *testing.T) {
+	assert.True(t, validRequestID("abc-123_DEF.4"))
+	assert.False(t, validRequestID(""))
+	assert.False(t, validRequestID("has space"))
+	assert.False(t, validRequestID("line\nbreak"))
+	assert.False(t, validRequestID(string(make([]byte, 65))))
+}
+
+func Test_logURI(t *testing.T) {
+	for uri, want := range map[string]string{
+		"/b/123":                        "/b/123",
+		"/b/123?template=4ls":           "/b/123?template=4ls",
+		"/b/123?invite=secret":          "/b/123?invite=REDACTED",
+		"/b/123/ws?u=bob&t=ticket":      "/b/123/ws?t=REDACTED&u=bob",
+		"/auth/callback?code=c&state=s": "/auth/callback?code=REDACTED&state=REDACTED",
+	} {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		assert.Equal(t, want, logURI(u), uri)
+	}
+}
+
+func Test_safeRedirect(t *testing.T) {
+	assert.Equal(t, "/b/123", safeRedirect("/b/123"))
+	assert.Equal(t, "/b/123?x=1", safeRedirect("/b/123?x=1"))
+	assert.Equal(t, "/", safeRedirect(""))
+	assert.Equal(t, "/", safeRedirect("https://evil.com"))
+	assert.Equal(t, "/", safeRedirect("//evil.com"))
+	assert.Equal(t, "/", safeRedirect("/\\evil.com"))
+	assert.Equal(t, "/", safeRedirect("evil.com"))
+}
+
+func Test_checkOrigin(t *testing.T) {
+	check := checkOrigin([]string{"https://retro.example.com"})
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://localhost:8080", true},
+		{"https://retro.example.com", true},
+		{"https://RETRO.example.com", true},
+		{"http://retro.example.com", false},
+		{"https://evil.example.com", false},
+		{"http://localhost:9090", false},
+		{"://", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:8080/b/1/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		assert.Equal(t, tt.want, check(r), tt.origin)
+	}
+}
+
+func Test_parseOrigins(t *testing.T) {
+	origins, err := parseOrigins("https://Retro.example.com, http://localhost:5173/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://retro.example.com", "http://localhost:5173"}, origins)
+
+	for _, bad := range []string{"retro.example.com", "ftp://retro.example.com", "https://retro.example.com/path"} {
+		_, err := parseOrigins(bad)
+		assert.Error(t, err, bad)
+	}
+}
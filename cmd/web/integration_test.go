@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_multipleInstances(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	srv := natstest.Server(t)
+	instanceA, instanceB := testInstance(t, srv), testInstance(t, srv)
+	boardID := uuid.New()
+
+	alice := join(t, instanceA, boardID, "alice")
+	bob := join(t, instanceB, boardID, "bob")
+
+	withName := func(name string) func(map[string]any) bool {
+		return func(obj map[string]any) bool { return obj["name"] == name }
+	}
+	clientOf := func(name string) func(map[string]any) bool {
+		return func(obj map[string]any) bool {
+			return obj["user"].(map[string]any)["name"] == name
+		}
+	}
+
+	// presence
+	alice.waitForObject("clients", clientOf("bob"))
+	bob.waitForObject("clients", clientOf("alice"))
+
+	// cards
+	col := alice.waitForObject("columns", withName("Good"))
+	bob.waitForObject("columns", withName("Good"))
+
+	alice.send("card.new", map[string]any{"name": "ship it", "column_id": col["id"]})
+	card := bob.waitForObject("cards", withName("ship it"))
+	cardID := card["id"].(string)
+	assert.Equal(t, col["id"], card["column_id"])
+	alice.waitForObject("cards", withName("ship it"))
+
+	// concurrent votes from both instances
+	bob.send("card.vote", map[string]any{"id": cardID, "vote": 1})
+	alice.send("card.vote", map[string]any{"id": cardID, "vote": 1})
+	hasVotes := func(obj map[string]any) bool { return obj["id"] == cardID && obj["votes"] == float64(2) }
+	alice.waitForObject("cards", hasVotes)
+	bob.waitForObject("cards", hasVotes)
+
+	// timer commands from either instance reach the single timer, state is the same everywhere
+	isRunning := func(m map[string]any) bool {
+		return m["data"].(map[string]any)["status"] == "running"
+	}
+	bob.send("timer.cmd", map[string]any{"cmd": "start", "value": "1m"})
+	aliceState := alice.waitFor("timer.state", isRunning)["data"].(map[string]any)
+	bobState := bob.waitFor("timer.state", isRunning)["data"].(map[string]any)
+	assert.Equal(t, aliceState["ends_at"], bobState["ends_at"])
+
+	alice.send("timer.cmd", map[string]any{"cmd": "pause"})
+	isPaused := func(m map[string]any) bool {
+		return m["data"].(map[string]any)["status"] == "paused"
+	}
+	pausedA := alice.waitFor("timer.state", isPaused)["data"].(map[string]any)
+	pausedB := bob.waitFor("timer.state", isPaused)["data"].(map[string]any)
+	assert.Equal(t, pausedA["remaining"], pausedB["remaining"])
+
+	// late joiner gets current timer state from any instance
+	carol := join(t, instanceB, boardID, "carol")
+	carol.waitFor("timer.state", isPaused)
+
+	// leave
+	bob.conn.Close()
+	alice.waitFor("clients", func(m map[string]any) bool { return m["op"] == "del" })
+}
+
+// waitForStatus waits until GET path on instance returns given status code
+func waitForStatus(t *testing.T, instance *httptest.Server, path string, status int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(instance.URL + path)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == status
+	}, convergeTimeout, 10*time.Millisecond, "waiting for %s to return %d", path, status)
+}
+
+func Test_natsReconnect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	storeDir := t.TempDir()
+	srv := natstest.Run(t, server.RANDOM_PORT, storeDir)
+	instance := testInstance(t, srv)
+	boardID := uuid.New()
+
+	alice := join(t, instance, boardID, "alice")
+	col := alice.waitForObject("columns", func(obj map[string]any) bool { return obj["name"] == "Good" })
+	alice.send("timer.cmd", map[string]any{"cmd": "start", "value": "1m"})
+	alice.waitFor("timer.state", func(m map[string]any) bool {
+		return m["data"].(map[string]any)["status"] == "running"
+	})
+
+	// instance stays alive but not ready while NATS is down
+	port := srv.Addr().(*net.TCPAddr).Port
+	srv.Shutdown()
+	srv.WaitForShutdown()
+	waitForStatus(t, instance, "/health/ready", http.StatusServiceUnavailable)
+	waitForStatus(t, instance, "/health/live", http.StatusOK)
+
+	natstest.Run(t, port, storeDir)
+	waitForStatus(t, instance, "/health/ready", http.StatusOK)
+
+	// same websocket keeps receiving board changes and timer messages
+	alice.send("card.new", map[string]any{"name": "still here", "column_id": col["id"]})
+	alice.waitForObject("cards", func(obj map[string]any) bool { return obj["name"] == "still here" })
+
+	alice.send("timer.cmd", map[string]any{"cmd": "pause"})
+	alice.waitFor("timer.state", func(m map[string]any) bool {
+		return m["data"].(map[string]any)["status"] == "paused"
+	})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/issues"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+// issueClaimTTL is how long a card is claimed while its issue is being created,
+// claim is taken over after that e.g when the instance creating it crashed.
+const issueClaimTTL = time.Minute
+
+// errIssueExists returned when card has an issue, or one is being created
+var errIssueExists = errors.New("card already has an issue")
+
+// issueTrackerName returns name of the issue tracker, empty when it's not configured
+func (a *app) issueTrackerName() string {
+	if a.issues == nil {
+		return ""
+	}
+	return a.issues.Name()
+}
+
+// createCardIssue creates issue of the card in the issue tracker, its URL is stored on the card so everyone sees it
+func (a *app) createCardIssue(w http.ResponseWriter, r *http.Request) {
+	if a.issues == nil {
+		a.clientError(w, r, http.StatusNotFound, errors.New("issue tracker not configured"))
+		return
+	}
+	// nothing to read, JSON body is still required so the issue can't be created cross-site
+	var input struct{}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	if b.ReadOnly() {
+		a.clientErrorMessage(w, r, http.StatusLocked, errors.New("board is locked or archived"))
+		return
+	}
+	cardID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+
+	// card is claimed first, so concurrent requests don't create more issues of it
+	ctx := r.Context()
+	card, err := a.store.Cards.Update(ctx, b.ID, cardID, func(card *models.Card) error {
+		if card.IssueURL != "" || time.Since(time.Unix(card.IssueClaimedAt, 0)) < issueClaimTTL {
+			return errIssueExists
+		}
+		card.IssueClaimedAt = time.Now().Unix()
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, errIssueExists) {
+		a.clientErrorMessage(w, r, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Cards.Update: %s", err.Error()))
+		return
+	}
+	column, err := a.store.Columns.Get(ctx, b.ID, card.ColumnID)
+	if err != nil {
+		a.releaseIssueClaim(r, card)
+		a.serverError(w, r, fmt.Errorf("error a.store.Columns.Get: %s", err.Error()))
+		return
+	}
+
+	issue := issues.Issue{
+		Title: card.Name,
+		Body:  fmt.Sprintf("From %s of retro board %s (%d votes).", column.Name, a.externalURL(r, "/b/"+b.ID.String()), card.Votes),
+	}
+	url, err := a.issues.Create(ctx, issue)
+	if err != nil {
+		a.releaseIssueClaim(r, card)
+		a.requestLogger(r).Error(err.Error(), "type", "issue-tracker-error", "card_id", card.ID)
+		http.Error(w, fmt.Sprintf("failed to create %s issue: %s", a.issues.Name(), err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	// card may have been changed while the issue was created, only its issue is set
+	card, err = a.store.Cards.Update(ctx, b.ID, cardID, func(card *models.Card) error {
+		card.IssueURL = url
+		card.IssueClaimedAt = 0
+		return nil
+	})
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Cards.Update: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("card issue created", "board_id", b.ID, "card_id", card.ID, "url", url)
+	writeJSON(w, http.StatusCreated, card)
+}
+
+// releaseIssueClaim releases claim of the card when its issue couldn't be created, so it can be tried again
+func (a *app) releaseIssueClaim(r *http.Request, card *models.Card) {
+	_, err := a.store.Cards.Update(context.WithoutCancel(r.Context()), card.BoardID, card.ID, func(c *models.Card) error {
+		c.IssueClaimedAt = 0
+		return nil
+	})
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		a.requestLogger(r).Error(fmt.Sprintf("error releasing issue claim: %s", err.Error()), "card_id", card.ID)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/issues"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cardIssue(t *testing.T) {
+	var mu sync.Mutex
+	var created []map[string]string
+	github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var issue map[string]string
+		json.NewDecoder(r.Body).Decode(&issue)
+		mu.Lock()
+		created = append(created, issue)
+		n := len(created)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"html_url": "https://github.com/acme/retro/issues/%d"}`, n)
+	}))
+	defer github.Close()
+
+	a := testApp(t, natstest.Server(t), testURL, func(a *app, _ string) {
+		gh, err := issues.NewGitHub(github.URL, "acme/retro", "token")
+		require.NoError(t, err)
+		a.issues = gh
+	})
+	boardID := uuid.New()
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, boardID))
+	bob := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, bob, boardID))
+
+	ctx := context.Background()
+	actions := boardColumn(t, a, boardID, "Action items")
+	newCard := func(name string) *models.Card {
+		t.Helper()
+		card, err := a.manager.Handle(ctx, boardID, profileOf(t, alice), "card.new", map[string]any{"name": name, "column_id": actions.ID.String()})
+		require.NoError(t, err)
+		return card.(*models.Card)
+	}
+	card := newCard("fix ci")
+	issueURL := fmt.Sprintf("%s/b/%s/cards/%s/issue", testURL, boardID, card.ID)
+
+	// only facilitator creates issues
+	code, _ := postJSON(t, bob, http.MethodPost, issueURL, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// form post, like the one from other site, is rejected
+	resp, err := alice.PostForm(issueURL, url.Values{})
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	mu.Lock()
+	assert.Empty(t, created)
+	mu.Unlock()
+
+	code, updated := postJSON(t, alice, http.MethodPost, issueURL, nil)
+	require.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "https://github.com/acme/retro/issues/1", updated["issue_url"])
+	stored, err := a.store.Cards.Get(ctx, boardID, card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/retro/issues/1", stored.IssueURL)
+	mu.Lock()
+	assert.Equal(t, "fix ci", created[0]["title"])
+	assert.Contains(t, created[0]["body"], fmt.Sprintf("From Action items of retro board %s/b/%s", testURL, boardID))
+	mu.Unlock()
+
+	// issue URL is kept on card changes, and the card gets only one issue
+	changed, err := a.manager.Handle(ctx, boardID, profileOf(t, alice), "card.update", map[string]any{"id": card.ID.String(), "name": "fix ci now"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/retro/issues/1", changed.(*models.Card).IssueURL)
+	code, _ = postJSON(t, alice, http.MethodPost, issueURL, nil)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// concurrent requests create only one issue
+	card = newCard("add tests")
+	issueURL = fmt.Sprintf("%s/b/%s/cards/%s/issue", testURL, boardID, card.ID)
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for range cap(codes) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := alice.Post(issueURL, "application/json", strings.NewReader("null"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			resp.Body.Close()
+			codes <- resp.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	count := map[int]int{}
+	for code := range codes {
+		count[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: 4}, count)
+	mu.Lock()
+	assert.Len(t, created, 2)
+	mu.Unlock()
+}
@@ -4,17 +4,34 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/auth"
 	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/issues"
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/natsutil"
 	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/ekaputra07/go-retro/internal/store/natstore"
+	"github.com/ekaputra07/go-retro/internal/tracing"
+	"github.com/ekaputra07/go-retro/internal/webhook"
 	"github.com/gorilla/sessions"
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
 )
 
+// Time to wait for JetStream of embedded NATS server to be ready,
+// in cluster mode it's ready once quorum of the servers are up.
+const jetStreamReadyTimeout = time.Minute
+
 type app struct {
 	config  config
 	logger  *slog.Logger
@@ -22,6 +39,18 @@ type app struct {
 	manager *board.BoardManager
 	session *sessions.CookieStore
 	nats    *natsutil.NATS
+	oidc    *auth.OIDC     // nil when login disabled
+	issues  issues.Tracker // nil when issue tracker not configured
+
+	webhooks *webhook.Worker
+
+	passcodeAttempts *attemptLimiter // per board and client IP
+	avatarUploads    chan struct{}   // semaphore of avatar uploads being processed
+
+	upgrader websocket.Upgrader
+
+	clients      sync.WaitGroup // connected websocket clients
+	shuttingDown atomic.Bool
 }
 
 func main() {
@@ -29,41 +58,205 @@ func main() {
 	c := parseConfig()
 
 	// logger
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	logger := newLogger(c.logFormat, c.logLevel)
+	slog.SetDefault(logger)
 
-	// session store
-	session := sessions.NewCookieStore([]byte(c.secret))
-	session.Options = &sessions.Options{Secure: c.secure}
+	// exit code set on error, exit only after all deferred cleanups done
+	exitCode := 0
+	defer func() { os.Exit(exitCode) }()
+
+	// tracing
+	ctx := context.Background()
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Endpoint:    c.otlpEndpoint,
+		Insecure:    c.otlpInsecure,
+		SampleRatio: c.traceSampling,
+		ServiceName: "goretro-web",
+		Version:     appVersion,
+	})
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer shutdownTracing(ctx)
+
+	// NATS, either embedded or external server
+	var nc *natsutil.NATS
+	if c.natsEmbedded {
+		srv, err := natsutil.RunServer(c.natsServer)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to start embedded NATS server: %s", err.Error()))
+			exitCode = 1
+			return
+		}
+		defer srv.Shutdown()
+		logger.Info("embedded NATS server started", "name", c.natsServer.Name, "data_dir", c.natsServer.DataDir)
+
+		waitCtx, cancel := context.WithTimeout(ctx, jetStreamReadyTimeout)
+		err = natsutil.WaitJetStream(waitCtx, srv)
+		cancel()
+		if err != nil {
+			logger.Error(err.Error())
+			exitCode = 1
+			return
+		}
 
-	// NATS
-	nc := natsutil.Connect(c.natsUrl, c.natsCreds)
+		if nc, err = natsutil.ConnectEmbedded(srv, logger); err != nil {
+			logger.Error(err.Error())
+			exitCode = 1
+			return
+		}
+	} else if nc, err = natsutil.Connect(c.natsUrl, c.natsCreds, logger); err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to NATS: %s", err.Error()))
+		exitCode = 1
+		return
+	}
 	defer nc.Close()
 
 	// database
-	ctx := context.Background()
 	db, err := natstore.NewStore(ctx, nc, "goretro")
 	if err != nil {
 		logger.Error(err.Error())
-		os.Exit(1)
+		exitCode = 1
+		return
+	}
+
+	a := newApp(c, logger, nc, db)
+	if c.oidc.Enabled() {
+		if a.oidc, err = auth.NewOIDC(ctx, c.oidc); err != nil {
+			logger.Error(err.Error())
+			exitCode = 1
+			return
+		}
+	}
+	if c.githubRepo != "" {
+		if a.issues, err = issues.NewGitHub(c.githubURL, c.githubRepo, c.githubToken); err != nil {
+			logger.Error(err.Error())
+			exitCode = 1
+			return
+		}
+	}
+
+	// board manager, stopped only after all clients gone so timers are handed off last
+	managerCtx, stopManager := context.WithCancel(ctx)
+	managerDone := make(chan struct{})
+	go func() {
+		a.manager.Start(managerCtx)
+		close(managerDone)
+	}()
+
+	// webhook worker, stopped along with the board manager
+	webhooksDone := make(chan struct{})
+	go func() {
+		if err := a.webhooks.Start(managerCtx); err != nil {
+			logger.Error(err.Error())
+		}
+		close(webhooksDone)
+	}()
+
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err = a.serve(signalCtx); err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+	}
+
+	stopManager()
+	<-managerDone
+	<-webhooksDone
+	logger.Info("bye!")
+}
+
+// newApp creates app instance along with its session store and board manager
+func newApp(c config, logger *slog.Logger, nc *natsutil.NATS, db *store.Store) *app {
+	session := sessions.NewCookieStore([]byte(c.secret))
+	// session is shared by board and auth pages
+	session.Options = &sessions.Options{Path: "/", Secure: c.secure}
+
+	return &app{
+		config:           c,
+		logger:           logger,
+		store:            db,
+		manager:          board.NewBoardManager(logger, nc, db, strings.Split(c.initialColumns, ",")),
+		session:          session,
+		nats:             nc,
+		webhooks:         webhook.NewWorker(logger, nc, db, c.webhooks),
+		passcodeAttempts: newAttemptLimiter(rate.Every(passcodeAttemptInterval), passcodeAttemptBurst),
+		avatarUploads:    make(chan struct{}, maxConcurrentAvatarUploads),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin(c.allowedOrigins),
+		},
+	}
+}
+
+// serve serves http requests until ctx is done, then gracefully shuts down the server:
+// stop accepting new connections, wait for in-flight requests and close all websocket clients.
+func (a *app) serve(ctx context.Context) error {
+	// websocket connections are hijacked so they're not closed by server shutdown,
+	// they're closed by cancelling their base context instead.
+	connCtx, closeConns := context.WithCancel(context.Background())
+	defer closeConns()
+
+	srv := &http.Server{
+		Addr:        fmt.Sprintf(":%d", a.config.port),
+		Handler:     a.routes(),
+		BaseContext: func(net.Listener) context.Context { return connCtx },
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		a.logger.Info(fmt.Sprintf("%s (%s) running on :%d", appName, appVersion, a.config.port))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	// metrics served on their own port, so they can be kept away from the public
+	var metricsSrv *http.Server
+	if a.config.metricsPort > 0 {
+		metricsSrv = &http.Server{
+			Addr:    fmt.Sprintf(":%d", a.config.metricsPort),
+			Handler: metrics.Handler(),
+		}
+		go func() {
+			a.logger.Info(fmt.Sprintf("metrics exposed on :%d", a.config.metricsPort))
+			errCh <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		if metricsSrv != nil {
+			metricsSrv.Close()
+		}
+		srv.Close()
+		return err
+	case <-ctx.Done():
 	}
 
-	// board manager
-	manager := board.NewBoardManager(logger, nc, db, strings.Split(c.initialColumns, ","))
-	ctx, cancel := context.WithCancel(ctx)
+	a.logger.Info("shutting down...")
+	a.shuttingDown.Store(true)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.shutdownTimeout)
 	defer cancel()
-	go manager.Start(ctx)
-
-	a := &app{
-		config:  c,
-		logger:  logger,
-		store:   db,
-		manager: manager,
-		session: session,
-		nats:    nc,
+
+	// stop accepting new connections and wait for in-flight requests
+	err := srv.Shutdown(shutdownCtx)
+	if metricsSrv != nil {
+		metricsSrv.Shutdown(shutdownCtx)
 	}
 
-	logger.Info(fmt.Sprintf("%s (%s) running on :%d", appName, appVersion, c.port))
-	err = http.ListenAndServe(fmt.Sprintf(":%d", c.port), a.routes())
-	logger.Error(err.Error())
-	os.Exit(1)
+	closeConns()
+	closed := make(chan struct{})
+	go func() {
+		a.clients.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		a.logger.Info("all websocket clients closed")
+	case <-shutdownCtx.Done():
+		a.logger.Warn("timeout waiting for websocket clients to close")
+	}
+	return err
 }
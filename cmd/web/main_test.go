@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/auth"
+	"github.com/ekaputra07/go-retro/internal/auth/authtest"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/store/natstore"
+	"github.com/ekaputra07/go-retro/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Time to wait for a message to arrive before failing the test.
+const convergeTimeout = 5 * time.Second
+
+// testURL is where the app is reached by testBrowser, no server is listening on it.
+const testURL = "http://goretro.test"
+
+// testApp creates app connected to srv, its board manager and webhook worker run until the test ends.
+// opts are applied before any request, url is where the app is reached.
+func testApp(t *testing.T, srv *server.Server, url string, opts ...func(a *app, url string)) *app {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	n, err := natsutil.Connect(srv.ClientURL(), "none", logger)
+	require.NoError(t, err)
+
+	db, err := natstore.NewStore(context.Background(), n, "goretro")
+	require.NoError(t, err)
+
+	c := config{
+		secret:         "integration-test-secret",
+		initialColumns: "Good,Bad,Action items",
+		webhooks:       webhook.Config{Backoff: []time.Duration{10 * time.Millisecond}, AllowPrivate: true},
+	}
+	a := newApp(c, logger, n, db)
+	for _, opt := range opts {
+		opt(a, url)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	managerDone := make(chan struct{})
+	go func() {
+		a.manager.Start(ctx)
+		close(managerDone)
+	}()
+	webhooksDone := make(chan struct{})
+	go func() {
+		assert.NoError(t, a.webhooks.Start(ctx))
+		close(webhooksDone)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-managerDone
+		<-webhooksDone
+		n.Conn.Close()
+	})
+	return a
+}
+
+// testInstance serves an app instance connected to srv, as if it's running on its own machine.
+// It's needed by tests using websocket, others use testBrowser.
+func testInstance(t *testing.T, srv *server.Server, opts ...func(a *app, url string)) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(nil)
+	ts.Config.Handler = testApp(t, srv, "http://"+ts.Listener.Addr().String(), opts...).routes()
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// withOIDC enables login with idp
+func withOIDC(t *testing.T, idp *authtest.IdP) func(a *app, url string) {
+	return func(a *app, url string) {
+		a.config.oidc = auth.Config{Issuer: idp.URL, ClientID: idp.ClientID, ClientSecret: "secret", RedirectURL: url + "/auth/callback"}
+		o, err := auth.NewOIDC(context.Background(), a.config.oidc)
+		require.NoError(t, err)
+		a.oidc = o
+	}
+}
+
+// testBrowser returns http client with its own session, requests to testURL are served by the app handler directly.
+func testBrowser(t *testing.T, a *app) *http.Client {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return &http.Client{Jar: jar, Transport: appTransport{a.routes()}}
+}
+
+// appTransport serves requests to testURL with handler as if they came through a server,
+// other requests (e.g to OIDC provider) go through the network.
+type appTransport struct {
+	handler http.Handler
+}
+
+func (tr appTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Scheme+"://"+r.URL.Host != testURL {
+		return http.DefaultTransport.RoundTrip(r)
+	}
+	r = r.Clone(r.Context())
+	r.Host = r.URL.Host
+	r.RequestURI = r.URL.RequestURI()
+	r.RemoteAddr = "192.0.2.1:1234"
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	rec := httptest.NewRecorder()
+	tr.handler.ServeHTTP(rec, r)
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+// visit opens the board page, user of the session is created on first visit
+func visit(t *testing.T, httpClient *http.Client, boardID uuid.UUID) int {
+	t.Helper()
+	resp, err := httpClient.Get(fmt.Sprintf("%s/b/%s", testURL, boardID))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// profileOf returns user of the session as shown on the profile page
+func profileOf(t *testing.T, httpClient *http.Client) models.User {
+	t.Helper()
+	resp, err := httpClient.Get(testURL + "/profile")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var user models.User
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	return user
+}
+
+// testClient is a user connected to a board through websocket,
+// it keeps all received messages so assertions don't depend on the order messages arrive.
+type testClient struct {
+	t        *testing.T
+	conn     *websocket.Conn
+	mu       sync.Mutex
+	received []map[string]any
+	closed   bool
+	closeErr error         // error the connection is closed with, e.g websocket.CloseError
+	notify   chan struct{} // signaled when new message received
+}
+
+// join opens the board page on given instance (to get session) then connects to its websocket
+func join(t *testing.T, instance *httptest.Server, boardID uuid.UUID, name string) *testClient {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return joinWith(t, &http.Client{Jar: jar}, instance, boardID, name)
+}
+
+// joinWith joins the board with session of given http client
+func joinWith(t *testing.T, httpClient *http.Client, instance *httptest.Server, boardID uuid.UUID, name string) *testClient {
+	t.Helper()
+	resp, err := httpClient.Get(fmt.Sprintf("%s/b/%s", instance.URL, boardID))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	conn, _, err := dial(httpClient, instance, boardID, name)
+	require.NoError(t, err)
+
+	c := &testClient{t: t, conn: conn, notify: make(chan struct{}, 1)}
+	go c.read()
+	t.Cleanup(func() { conn.Close() })
+
+	c.send("me", nil)
+	c.waitFor("me", func(m map[string]any) bool { return true })
+	return c
+}
+
+// dial opens board websocket with session of given http client
+func dial(httpClient *http.Client, instance *httptest.Server, boardID uuid.UUID, name string) (*websocket.Conn, *http.Response, error) {
+	return dialWithTicket(httpClient, instance, boardID, name, wsTicket(httpClient, instance.URL, boardID))
+}
+
+// wsTicket gets websocket ticket as the board page does, empty when it's not given
+func wsTicket(httpClient *http.Client, baseURL string, boardID uuid.UUID) string {
+	resp, err := httpClient.Get(fmt.Sprintf("%s/b/%s/ws/ticket", baseURL, boardID))
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	var data struct {
+		Ticket string `json:"ticket"`
+	}
+	json.NewDecoder(resp.Body).Decode(&data)
+	return data.Ticket
+}
+
+func dialWithTicket(httpClient *http.Client, instance *httptest.Server, boardID uuid.UUID, name, ticket string, header ...http.Header) (*websocket.Conn, *http.Response, error) {
+	dialer := websocket.Dialer{Jar: httpClient.Jar}
+	wsURL := fmt.Sprintf("%s/b/%s/ws?u=%s&t=%s", strings.Replace(instance.URL, "http", "ws", 1), boardID, name, url.QueryEscape(ticket))
+	var h http.Header
+	if len(header) > 0 {
+		h = header[0]
+	}
+	return dialer.Dial(wsURL, h)
+}
+
+// read reads messages from the socket, message list is unpacked into individual messages
+func (c *testClient) read() {
+	for {
+		var m map[string]any
+		err := c.conn.ReadJSON(&m)
+
+		c.mu.Lock()
+		if err != nil {
+			c.closed = true
+			c.closeErr = err
+		} else if m["type"] == "messages" {
+			for _, item := range m["messages"].([]any) {
+				c.received = append(c.received, item.(map[string]any))
+			}
+		} else {
+			c.received = append(c.received, m)
+		}
+		c.mu.Unlock()
+
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// find returns received message of given type that satisfies match
+func (c *testClient) find(typ string, match func(m map[string]any) bool) (map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range c.received {
+		if m["type"] == typ && match(m) {
+			return m, false
+		}
+	}
+	return nil, c.closed
+}
+
+func (c *testClient) send(typ string, data map[string]any) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.WriteJSON(map[string]any{"type": typ, "data": data}))
+}
+
+// waitFor waits until message of given type that satisfies match received
+func (c *testClient) waitFor(typ string, match func(m map[string]any) bool) map[string]any {
+	c.t.Helper()
+	timeout := time.After(convergeTimeout)
+	for {
+		m, closed := c.find(typ, match)
+		if m != nil {
+			return m
+		}
+		if closed {
+			c.t.Fatalf("connection closed while waiting for %s", typ)
+		}
+		select {
+		case <-c.notify:
+		case <-timeout:
+			c.t.Fatalf("timeout waiting for %s", typ)
+			return nil
+		}
+	}
+}
+
+// waitClosed waits until the connection closed, returns the error it's closed with
+func (c *testClient) waitClosed() error {
+	c.t.Helper()
+	timeout := time.After(convergeTimeout)
+	for {
+		c.mu.Lock()
+		closed, err := c.closed, c.closeErr
+		c.mu.Unlock()
+		if closed {
+			return err
+		}
+		select {
+		case <-c.notify:
+		case <-timeout:
+			c.t.Fatal("timeout waiting for connection closed")
+			return nil
+		}
+	}
+}
+
+// waitForObject waits for stream put of given type with object that satisfies match
+func (c *testClient) waitForObject(typ string, match func(obj map[string]any) bool) map[string]any {
+	c.t.Helper()
+	m := c.waitFor(typ, func(m map[string]any) bool {
+		obj, ok := m["obj"].(map[string]any)
+		return m["op"] == "put" && ok && match(obj)
+	})
+	return m["obj"].(map[string]any)
+}
+
+// postJSON sends JSON request and returns response status code along with its decoded body (if any)
+func postJSON(t *testing.T, httpClient *http.Client, method, url string, body any) (int, map[string]any) {
+	t.Helper()
+	b, err := json.Marshal(body)
+	require.NoError(t, err)
+	req, err := http.NewRequest(method, url, bytes.NewReader(b))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var data map[string]any
+	if resp.Header.Get("Content-Type") == "application/json" {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	}
+	return resp.StatusCode, data
+}
+
+// apiJSON sends REST API request authenticated with the token, nil body is sent as no body
+func apiJSON(t *testing.T, httpClient *http.Client, token, method, url string, body any) (int, map[string]any) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var data map[string]any
+	if resp.Header.Get("Content-Type") == "application/json" {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&data))
+	}
+	return resp.StatusCode, data
+}
+
+// boardColumn returns column of the board with given name
+func boardColumn(t *testing.T, a *app, boardID uuid.UUID, name string) models.Column {
+	t.Helper()
+	columns, err := a.store.Columns.List(context.Background(), boardID, 10)
+	require.NoError(t, err)
+	for _, col := range columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	require.FailNow(t, "column not found", name)
+	return models.Column{}
+}
@@ -1,20 +1,38 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// requestID assigns an ID to each request, reuses X-Request-ID header when it's valid.
+// The ID is returned in response header and included in request logs.
+func (a *app) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // logrequest logs each request
 func (a *app) logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
-			ip     = r.RemoteAddr
+			ip     = clientIP(r, a.config.trustedProxies)
 			proto  = r.Proto
 			method = r.Method
-			uri    = r.URL.RequestURI()
+			uri    = logURI(r.URL)
 		)
-		a.logger.Info("request received", "ip", ip, "proto", proto, "method", method, "uri", uri)
+		a.requestLogger(r).Info("request received", "ip", ip, "proto", proto, "method", method, "uri", uri)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -52,3 +70,8 @@ func staticCache(next http.Handler, maxAgeSecond int) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// traced starts tracing span named after route pattern for each request
+func traced(pattern string, handler http.HandlerFunc) http.Handler {
+	return otelhttp.NewHandler(handler, pattern)
+}
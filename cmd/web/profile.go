@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/google/uuid"
+)
+
+// profile returns the session user
+func (a *app) profile(w http.ResponseWriter, r *http.Request) {
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(r.Context(), session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, user)
+}
+
+// updateProfile changes name and avatar of the session user, only the fields present are changed.
+// Everyone on the boards the user is in sees the change right away.
+func (a *app) updateProfile(w http.ResponseWriter, r *http.Request) {
+	var input board.Profile
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+
+	a.respondProfile(w, r, user.ID, input)
+}
+
+// respondProfile updates profile of the user then responds with the updated user
+func (a *app) respondProfile(w http.ResponseWriter, r *http.Request, userID uuid.UUID, p board.Profile) {
+	updated, err := board.UpdateProfile(r.Context(), a.store, a.nats.Conn, userID, p)
+	switch {
+	case errors.Is(err, board.ErrInvalidProfile):
+		a.clientErrorMessage(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, board.ErrProfileManaged):
+		a.clientError(w, r, http.StatusForbidden, err)
+	case err != nil:
+		a.serverError(w, r, fmt.Errorf("error board.UpdateProfile: %s", err.Error()))
+	default:
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
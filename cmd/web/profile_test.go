@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_profile(t *testing.T) {
+	srv := natstest.Server(t)
+	instance := testInstance(t, srv)
+	boardID := uuid.New()
+	otherBoardID := uuid.New()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	alice := &http.Client{Jar: jar}
+	aliceWS := joinWith(t, alice, instance, boardID, "alice")
+	joinWith(t, alice, instance, otherBoardID, "alice")
+	bob := join(t, instance, boardID, "bob")
+	carol := join(t, instance, otherBoardID, "carol")
+
+	userOf := func(c *testClient, name string) map[string]any {
+		obj := c.waitForObject("clients", func(obj map[string]any) bool {
+			return obj["user"].(map[string]any)["name"] == name
+		})
+		return obj["user"].(map[string]any)
+	}
+
+	// changes via API are seen on all boards the user is in
+	code, user := postJSON(t, alice, http.MethodPost, instance.URL+"/profile", map[string]any{"name": "  Alice   Smith ", "avatar_id": 12})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Alice Smith", user["name"])
+	assert.Equal(t, float64(12), userOf(bob, "Alice Smith")["avatar_id"])
+	assert.Equal(t, float64(12), userOf(carol, "Alice Smith")["avatar_id"])
+
+	// and via websocket, only given fields changed
+	aliceWS.send("user.update", map[string]any{"name": "Ally"})
+	assert.Equal(t, float64(12), userOf(bob, "Ally")["avatar_id"])
+	userOf(carol, "Ally")
+
+	resp, err := alice.Get(instance.URL + "/profile")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	assert.Equal(t, "Ally", user["name"])
+
+	// names and avatars are validated
+	for _, input := range []map[string]any{
+		{"name": " "},
+		{"name": strings.Repeat("a", 33)},
+		{"avatar_id": -1},
+		{"avatar_id": 13},
+	} {
+		code, _ = postJSON(t, alice, http.MethodPost, instance.URL+"/profile", input)
+		assert.Equal(t, http.StatusBadRequest, code, input)
+	}
+	_, resp, err = dial(alice, instance, boardID, url.QueryEscape(strings.Repeat("a", 33)))
+	assert.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
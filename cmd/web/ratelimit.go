@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Passcode attempts allowed per board and client IP, refilled one every passcodeAttemptInterval.
+const (
+	passcodeAttemptBurst    = 5
+	passcodeAttemptInterval = 12 * time.Second
+)
+
+// attemptLimiter limits attempts per key, e.g guessing passcode of a board from one IP.
+// Idle keys are swept, so the number of keys it holds is bounded by how fast they are created.
+type attemptLimiter struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// allow reports whether an attempt with given key may be made now
+func (l *attemptLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[key] = b
+	}
+	return b.AllowN(now, 1)
+}
+
+// sweep removes buckets that have been refilled, they're no different from new ones
+func (l *attemptLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.TokensAt(now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func newAttemptLimiter(limit rate.Limit, burst int) *attemptLimiter {
+	return &attemptLimiter{
+		limit:     limit,
+		burst:     burst,
+		buckets:   make(map[string]*rate.Limiter),
+		lastSweep: time.Now(),
+	}
+}
@@ -11,11 +11,36 @@ func (a *app) routes() http.Handler {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /{$}", a.generateBoardID)
-	mux.HandleFunc("GET /health", a.health)
+	mux.HandleFunc("GET /health", a.ready)
+	mux.HandleFunc("GET /health/ready", a.ready)
+	mux.HandleFunc("GET /health/live", a.live)
+	mux.HandleFunc("GET /auth/login", a.login)
+	mux.HandleFunc("GET /auth/callback", a.loginCallback)
+	mux.HandleFunc("POST /auth/logout", a.logout)
+	mux.HandleFunc("GET /profile", a.profile)
+	mux.HandleFunc("POST /profile", a.updateProfile)
+	mux.HandleFunc("POST /profile/avatar", a.uploadAvatar)
+	mux.HandleFunc("DELETE /profile/avatar", a.deleteAvatar)
+	mux.HandleFunc("GET /profile/tokens", a.apiTokens)
+	mux.HandleFunc("POST /profile/tokens", a.createAPIToken)
+	mux.HandleFunc("DELETE /profile/tokens/{id}", a.deleteAPIToken)
+	mux.HandleFunc("GET /avatars/{user}", a.avatar)
 	mux.Handle("GET /static/", http.StripPrefix("/static/", fileServer))
-	mux.HandleFunc("GET /b/{board}", a.board)
-	mux.HandleFunc("/b/{board}/ws", a.websocket)
+	mux.Handle("GET /b/{board}", traced("GET /b/{board}", a.board))
+	mux.Handle("/b/{board}/ws", traced("/b/{board}/ws", a.websocket))
+	mux.HandleFunc("GET /b/{board}/ws/ticket", a.websocketTicket)
+	mux.HandleFunc("GET /b/{board}/summary", a.boardSummary)
+	mux.HandleFunc("POST /b/{board}/access", a.boardAccess)
+	mux.HandleFunc("POST /b/{board}/settings/access", a.updateBoardAccess)
+	mux.HandleFunc("POST /b/{board}/invites", a.createInvite)
+	mux.HandleFunc("DELETE /b/{board}/invites", a.revokeInvites)
+	mux.HandleFunc("GET /b/{board}/webhooks", a.boardWebhooks)
+	mux.HandleFunc("POST /b/{board}/webhooks", a.createWebhook)
+	mux.HandleFunc("DELETE /b/{board}/webhooks/{id}", a.deleteWebhook)
+	mux.HandleFunc("POST /b/{board}/cards/{id}/issue", a.createCardIssue)
+	mux.HandleFunc("POST /chatops/command", a.slashCommand)
+	mux.Handle("/api/", a.apiRoutes())
 
 	// apply common headers middleware to all routes
-	return a.recoverPanic(a.logRequest(commonHeaders(mux)))
+	return a.requestID(a.recoverPanic(a.logRequest(commonHeaders(mux))))
 }
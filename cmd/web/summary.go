@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+// boardSummary renders read-only summary of the board, it can be shared with people who didn't join the board.
+// Summary of private board, or board that requires authenticated user, is only shown to those who can join it.
+func (a *app) boardSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	boardID, err := uuid.Parse(r.PathValue("board"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	s, err := a.manager.Summary(ctx, boardID)
+	if errors.Is(err, store.ErrDeleted) {
+		a.clientError(w, r, http.StatusGone, err)
+		return
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.manager.Summary: %s", err.Error()))
+		return
+	}
+	if s.Board.Private || s.Board.RequireAuth {
+		session, _ := a.session.Get(r, SESSION_NAME)
+		user, err := a.sessionUser(ctx, session)
+		if err != nil {
+			a.clientError(w, r, http.StatusForbidden, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+			return
+		}
+		if err := checkBoardAccess(session, s.Board, user); err != nil {
+			a.clientError(w, r, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	a.renderTemplate(w, r, summaryTpl, "summary.html", http.StatusOK, summaryData{
+		AppName:     appName,
+		AppVersion:  appVersion,
+		BoardURL:    a.externalURL(r, "/b/"+boardID.String()),
+		Summary:     s,
+		GeneratedAt: time.Now().Unix(),
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_boardSummary(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL)
+	boardID := uuid.New()
+	summaryURL := fmt.Sprintf("%s/b/%s/summary", testURL, boardID)
+
+	get := func(httpClient *http.Client) (int, string) {
+		t.Helper()
+		resp, err := httpClient.Get(summaryURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+	anon := testBrowser(t, a)
+	code, _ := get(anon)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// alice and bob joined the board, added cards, voted and used the timer
+	ctx := context.Background()
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, boardID))
+	bob := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, bob, boardID))
+	for name, c := range map[string]*http.Client{"alice": alice, "bob": bob} {
+		code, _ := postJSON(t, c, http.MethodPost, testURL+"/profile", map[string]any{"name": name})
+		require.Equal(t, http.StatusOK, code)
+		require.NoError(t, a.store.Participants.Put(ctx, boardID, profileOf(t, c)))
+	}
+	card, err := a.manager.Handle(ctx, boardID, profileOf(t, alice), "card.new", map[string]any{"name": "pairing", "column_id": boardColumn(t, a, boardID, "Good").ID.String()})
+	require.NoError(t, err)
+	_, err = a.manager.Handle(ctx, boardID, profileOf(t, bob), "card.new", map[string]any{"name": "fix ci", "column_id": boardColumn(t, a, boardID, "Action items").ID.String()})
+	require.NoError(t, err)
+	_, err = a.manager.Handle(ctx, boardID, profileOf(t, bob), "card.vote", map[string]any{"id": card.(*models.Card).ID.String(), "vote": float64(1)})
+	require.NoError(t, err)
+	b, err := a.store.Boards.Get(ctx, boardID)
+	require.NoError(t, err)
+	starter := profileOf(t, alice)
+	require.NoError(t, a.store.Timers.Put(ctx, models.Timer{BoardID: boardID, Status: "running", StartedBy: &starter, UpdatedAt: b.CreatedAt}))
+
+	// summary is shown without joining the board
+	code, body := get(anon)
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "<strong>0m</strong>")
+	assert.Contains(t, body, "<strong>2</strong><span class=\"muted\">participants</span>")
+	assert.Contains(t, body, "<li>alice</li><li>bob</li>")
+	assert.Contains(t, body, "<tr><td>pairing</td><td>Good</td><td class=\"num\">1</td></tr>")
+	assert.Contains(t, body, "<tr><td>fix ci</td><td></td><td class=\"num\">0</td></tr>")
+
+	// summary of private board is only shown to those who can join it
+	code, _ = postJSON(t, alice, http.MethodPost, fmt.Sprintf("%s/b/%s/settings/access", testURL, boardID), map[string]any{"private": true, "passcode": "open-sesame"})
+	require.Equal(t, http.StatusNoContent, code)
+	code, _ = get(anon)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = get(alice)
+	assert.Equal(t, http.StatusOK, code)
+}
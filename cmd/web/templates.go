@@ -2,19 +2,45 @@ package main
 
 import (
 	"bytes"
+	"embed"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"net/http"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/ekaputra07/go-retro/web/ui"
 )
 
 var boardTpl = template.Must(template.ParseFS(ui.UiFS, "dist/*.html"))
 
+// pages rendered by the server only, they don't depend on the UI build
+//
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+var summaryTpl = template.Must(template.New("summary.html").Funcs(template.FuncMap{
+	"time":     formatTime,
+	"duration": formatDuration,
+	"percent":  percent,
+}).ParseFS(templatesFS, "templates/summary.html"))
+
 type templateData struct {
-	AppName    string
-	AppVersion string
-	AppTagline string
+	AppName      string
+	AppVersion   string
+	AppTagline   string
+	LoginEnabled bool
+	User         *models.User
+	// AccessRequired tells the UI to ask for passcode of private board
+	AccessRequired bool
+	// WSTicket is needed to open websocket connection to the board
+	WSTicket string
+	// IssueTracker is name of the tracker facilitator creates issues of cards in, empty when not configured
+	IssueTracker string
+	// Spectator only watches the board, the UI doesn't offer changing it
+	Spectator bool
 }
 
 type templateAndJSONData struct {
@@ -22,12 +48,13 @@ type templateAndJSONData struct {
 	JSONData template.JS
 }
 
-func newTemplateData(_ config) (*templateAndJSONData, error) {
-	data := templateData{
-		AppName:    appName,
-		AppVersion: appVersion,
-		AppTagline: appTagline,
-	}
+// newTemplateData returns data of the board page, app info is set along with the page data given
+func newTemplateData(c config, data templateData) (*templateAndJSONData, error) {
+	data.AppName = appName
+	data.AppVersion = appVersion
+	data.AppTagline = appTagline
+	data.LoginEnabled = c.oidc.Enabled()
+
 	// create JSON string version of the data
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -39,10 +66,45 @@ func newTemplateData(_ config) (*templateAndJSONData, error) {
 	}, nil
 }
 
+// summaryData is the data of board summary page
+type summaryData struct {
+	AppName     string
+	AppVersion  string
+	BoardURL    string
+	Summary     *board.Summary
+	GeneratedAt int64
+}
+
+// formatTime formats unix time (seconds) in UTC
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// formatDuration formats duration in hours and minutes, e.g 1h 5m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%dh %dm", d/time.Hour, (d%time.Hour)/time.Minute)
+}
+
+// percent returns n of total in percent, 0 when total is 0
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return n * 100 / total
+}
+
 func (a *app) render(w http.ResponseWriter, r *http.Request, status int, data any) {
+	a.renderTemplate(w, r, boardTpl, "index.html", status, data)
+}
+
+func (a *app) renderTemplate(w http.ResponseWriter, r *http.Request, tpl *template.Template, name string, status int, data any) {
 	// try to render the template, if error return
 	buf := new(bytes.Buffer)
-	if err := boardTpl.ExecuteTemplate(buf, "index.html", data); err != nil {
+	if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
 		a.serverError(w, r, err)
 		return
 	}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/auth"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	// API token expiry when not specified, the maximum is store.MaxAPITokenTTL.
+	defaultAPITokenTTL = 90 * 24 * time.Hour
+
+	// Maximum number of API tokens a user can have.
+	maxAPITokensPerUser = 20
+
+	// Maximum length of API token name.
+	maxAPITokenNameLength = 64
+)
+
+// apiTokens returns API tokens of the session user, without their secrets
+func (a *app) apiTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	tokens, err := a.store.Tokens.List(ctx, user.ID, maxAPITokensPerUser)
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tokens.List: %s", err.Error()))
+		return
+	}
+	slices.SortFunc(tokens, func(x, y models.APIToken) int { return cmp.Compare(x.CreatedAt, y.CreatedAt) })
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// createAPIToken creates API token of the session user, the token is only returned once.
+func (a *app) createAPIToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name string `json:"name"`
+		TTL  string `json:"ttl"` // e.g 720h
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > maxAPITokenNameLength {
+		a.clientErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("token name must be 1 to %d characters", maxAPITokenNameLength))
+		return
+	}
+	ttl := defaultAPITokenTTL
+	if input.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(input.TTL); err != nil || ttl <= 0 || ttl > store.MaxAPITokenTTL {
+			a.clientErrorMessage(w, r, http.StatusBadRequest, fmt.Errorf("invalid token ttl %q, maximum %s", input.TTL, store.MaxAPITokenTTL))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	tokens, err := a.store.Tokens.List(ctx, user.ID, maxAPITokensPerUser)
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tokens.List: %s", err.Error()))
+		return
+	}
+	if len(tokens) >= maxAPITokensPerUser {
+		a.clientErrorMessage(w, r, http.StatusConflict, fmt.Errorf("maximum %d tokens allowed", maxAPITokensPerUser))
+		return
+	}
+
+	token, record := auth.NewAPIToken(user.ID, input.Name, time.Now(), ttl)
+	if err := a.store.Tokens.Create(ctx, record); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tokens.Create: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("api token created", "id", record.ID, "user_id", user.ID)
+	writeJSON(w, http.StatusCreated, struct {
+		models.APIToken
+		Token string `json:"token"`
+	}{record, token})
+}
+
+// deleteAPIToken revokes API token of the session user
+func (a *app) deleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	ctx := r.Context()
+	session, _ := a.session.Get(r, SESSION_NAME)
+	user, err := a.sessionUser(ctx, session)
+	if err != nil {
+		a.clientError(w, r, http.StatusUnauthorized, fmt.Errorf("error a.sessionUser: %s", err.Error()))
+		return
+	}
+	if _, err := a.store.Tokens.Get(ctx, user.ID, id); errors.Is(err, store.ErrNotFound) {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tokens.Get: %s", err.Error()))
+		return
+	}
+	if err := a.store.Tokens.Delete(ctx, user.ID, id); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Tokens.Delete: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("api token revoked", "id", id, "user_id", user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/webhook"
+	"github.com/google/uuid"
+)
+
+// Number of latest deliveries shown for each webhook.
+const webhookDeliveriesShown = 10
+
+// boardWebhooks returns webhooks of the board along with their latest deliveries
+func (a *app) boardWebhooks(w http.ResponseWriter, r *http.Request) {
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	hooks, err := a.store.Webhooks.List(ctx, b.ID, webhook.MaxPerBoard)
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.List: %s", err.Error()))
+		return
+	}
+
+	type webhookWithDeliveries struct {
+		models.Webhook
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}
+	list := []webhookWithDeliveries{}
+	for _, hook := range hooks {
+		deliveries, err := a.store.Webhooks.ListDeliveries(ctx, hook.ID, webhookDeliveriesShown)
+		if err != nil {
+			a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.ListDeliveries: %s", err.Error()))
+			return
+		}
+		if deliveries == nil {
+			deliveries = []models.WebhookDelivery{}
+		}
+		list = append(list, webhookWithDeliveries{hook, deliveries})
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, list)
+}
+
+// createWebhook adds webhook to the board, its secret to verify requests is only returned once
+func (a *app) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"` // empty means all events
+	}
+	if err := readJSON(w, r, &input); err != nil {
+		a.clientError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	hook, err := webhook.New(b.ID, input.URL, input.Events)
+	if err != nil {
+		a.clientErrorMessage(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := r.Context()
+	hooks, err := a.store.Webhooks.List(ctx, b.ID, webhook.MaxPerBoard)
+	if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.List: %s", err.Error()))
+		return
+	}
+	if len(hooks) >= webhook.MaxPerBoard {
+		a.clientErrorMessage(w, r, http.StatusConflict, fmt.Errorf("maximum %d webhooks allowed", webhook.MaxPerBoard))
+		return
+	}
+	if err := a.store.Webhooks.Create(ctx, hook); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.Create: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("webhook created", "id", hook.ID, "board_id", b.ID)
+	writeJSON(w, http.StatusCreated, struct {
+		models.Webhook
+		Secret string `json:"secret"`
+	}{hook, hook.Secret})
+}
+
+// deleteWebhook removes webhook of the board, its pending deliveries are dropped
+func (a *app) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	}
+	b, ok := a.facilitatorBoard(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	if _, err := a.store.Webhooks.Get(ctx, b.ID, id); errors.Is(err, store.ErrNotFound) {
+		a.clientError(w, r, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.Get: %s", err.Error()))
+		return
+	}
+	if err := a.store.Webhooks.Delete(ctx, b.ID, id); err != nil {
+		a.serverError(w, r, fmt.Errorf("error a.store.Webhooks.Delete: %s", err.Error()))
+		return
+	}
+	a.requestLogger(r).Info("webhook deleted", "id", id, "board_id", b.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
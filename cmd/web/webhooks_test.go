@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/ekaputra07/go-retro/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webhooks(t *testing.T) {
+	a := testApp(t, natstest.Server(t), testURL)
+	boardID := uuid.New()
+	webhooksURL := fmt.Sprintf("%s/b/%s/webhooks", testURL, boardID)
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+	}))
+	defer receiver.Close()
+
+	alice := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, alice, boardID))
+
+	// only facilitator manages webhooks
+	bob := testBrowser(t, a)
+	require.Equal(t, http.StatusOK, visit(t, bob, boardID))
+	code, _ := postJSON(t, bob, http.MethodPost, webhooksURL, map[string]any{"url": receiver.URL})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = postJSON(t, alice, http.MethodPost, webhooksURL, map[string]any{"url": "ftp://example.com"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, hook := postJSON(t, alice, http.MethodPost, webhooksURL, map[string]any{"url": receiver.URL, "events": []string{"action_item.created"}})
+	require.Equal(t, http.StatusCreated, code)
+	secret := hook["secret"].(string)
+
+	// only action items are sent
+	ctx := context.Background()
+	user := profileOf(t, alice)
+	for name, column := range map[string]string{"nice": "Good", "fix ci": "Action items"} {
+		_, err := a.manager.Handle(ctx, boardID, user, "card.new", map[string]any{"name": name, "column_id": boardColumn(t, a, boardID, column).ID.String()})
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, convergeTimeout, 10*time.Millisecond)
+	mu.Lock()
+	req, body := received[0], bodies[0]
+	mu.Unlock()
+	assert.Equal(t, "action_item.created", req.Header.Get(webhook.EventHeader))
+	ts, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign(secret, ts, body), req.Header.Get(webhook.SignatureHeader))
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, "fix ci", event["data"].(map[string]any)["card"].(map[string]any)["name"])
+
+	// delivery is logged, secret is not shown again
+	var hooks []map[string]any
+	require.Eventually(t, func() bool {
+		resp, err := alice.Get(webhooksURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&hooks))
+		return len(hooks) == 1 && len(hooks[0]["deliveries"].([]any)) == 1
+	}, convergeTimeout, 10*time.Millisecond)
+	assert.NotContains(t, hooks[0], "secret")
+	assert.Equal(t, float64(http.StatusOK), hooks[0]["deliveries"].([]any)[0].(map[string]any)["status_code"])
+
+	code, _ = postJSON(t, alice, http.MethodDelete, fmt.Sprintf("%s/%s", webhooksURL, hook["id"]), nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = postJSON(t, alice, http.MethodDelete, fmt.Sprintf("%s/%s", webhooksURL, hook["id"]), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,173 @@
+// Package authtest provides mock OIDC provider for tests.
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const keyID = "test-key"
+
+// IdP is mock OIDC provider, login succeeds right away as the user set in Login.
+type IdP struct {
+	*httptest.Server
+	ClientID string
+
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	claims map[string]any           // claims of the user logging in next
+	codes  map[string]authorization // issued authorization codes
+}
+
+type authorization struct {
+	nonce     string
+	challenge string
+	claims    map[string]any
+}
+
+// NewIdP starts mock OIDC provider accepting given client ID, it should be closed when done
+func NewIdP(clientID string) *IdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	idp := &IdP{
+		ClientID: clientID,
+		key:      key,
+		claims:   map[string]any{"sub": "user"},
+		codes:    make(map[string]authorization),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("GET /authorize", idp.authorize)
+	mux.HandleFunc("POST /token", idp.token)
+	mux.HandleFunc("GET /jwks", idp.jwks)
+	idp.Server = httptest.NewServer(mux)
+	return idp
+}
+
+// Login sets claims (e.g sub, name) of the user that logs in next
+func (idp *IdP) Login(claims map[string]any) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.claims = claims
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (idp *IdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                                idp.URL,
+		"authorization_endpoint":                idp.URL + "/authorize",
+		"token_endpoint":                        idp.URL + "/token",
+		"jwks_uri":                              idp.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+// authorize redirects back to the client with authorization code, as if user logged in
+func (idp *IdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != idp.ClientID || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	idp.mu.Lock()
+	idp.codes[code] = authorization{nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), claims: idp.claims}
+	idp.mu.Unlock()
+
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// token exchanges authorization code (only once) for signed ID token
+func (idp *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   idp.URL,
+		"aud":   idp.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	idToken, err := idp.sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (idp *IdP) sign(claims map[string]any) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: idp.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", keyID),
+	)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+func (idp *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &idp.key.PublicKey, KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+}
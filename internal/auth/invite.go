@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// context of invite token signature
+const inviteContext = "goretro-invite-v1"
+
+var (
+	// ErrInvalidInvite returned when invite token is malformed or its signature doesn't match
+	ErrInvalidInvite = errors.New("invalid invite")
+
+	// ErrInviteExpired returned when invite token is past its expiry time
+	ErrInviteExpired = errors.New("invite expired")
+)
+
+// Invite grants access to private board until it expires,
+// or until its board access epoch changed (all invites revoked).
+// View-only invite (view link) only lets its users watch the board as spectators.
+type Invite struct {
+	BoardID   uuid.UUID
+	Epoch     int
+	ExpiresAt time.Time
+	ViewOnly  bool
+}
+
+// SignInvite returns signed invite token
+func SignInvite(secret string, invite Invite) string {
+	payload := fmt.Sprintf("%s.%d.%d", invite.BoardID, invite.Epoch, invite.ExpiresAt.Unix())
+	if invite.ViewOnly {
+		payload += ".view"
+	}
+	return sign(secret, inviteContext, payload)
+}
+
+// VerifyInvite verifies invite token signature and expiry time
+func VerifyInvite(secret, token string, now time.Time) (*Invite, error) {
+	payload, ok := verify(secret, inviteContext, token)
+	if !ok {
+		return nil, ErrInvalidInvite
+	}
+
+	parts := strings.Split(payload, ".")
+	viewOnly := len(parts) == 4 && parts[3] == "view"
+	if len(parts) != 3 && !viewOnly {
+		return nil, ErrInvalidInvite
+	}
+	boardID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return nil, ErrInvalidInvite
+	}
+	epoch, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, ErrInvalidInvite
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidInvite
+	}
+
+	invite := &Invite{BoardID: boardID, Epoch: epoch, ExpiresAt: time.Unix(expires, 0), ViewOnly: viewOnly}
+	if !now.Before(invite.ExpiresAt) {
+		return nil, ErrInviteExpired
+	}
+	return invite, nil
+}
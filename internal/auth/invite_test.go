@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvite(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	invite := Invite{BoardID: uuid.New(), Epoch: 3, ExpiresAt: now.Add(time.Hour)}
+	token := SignInvite("secret", invite)
+
+	got, err := VerifyInvite("secret", token, now)
+	require.NoError(t, err)
+	assert.Equal(t, invite, *got)
+
+	_, err = VerifyInvite("secret", token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInviteExpired)
+
+	_, err = VerifyInvite("other-secret", token, now)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	// tampered payload e.g to use older epoch
+	signature := token[strings.LastIndexByte(token, '.'):]
+	tampered := fmt.Sprintf("%s.%d.%d%s", invite.BoardID, 2, invite.ExpiresAt.Unix(), signature)
+	_, err = VerifyInvite("secret", tampered, now)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	// view link can't be turned into invite
+	invite.ViewOnly = true
+	viewToken := SignInvite("secret", invite)
+	got, err = VerifyInvite("secret", viewToken, now)
+	require.NoError(t, err)
+	assert.Equal(t, invite, *got)
+	_, err = VerifyInvite("secret", strings.Replace(viewToken, ".view", "", 1), now)
+	assert.ErrorIs(t, err, ErrInvalidInvite)
+
+	for _, bad := range []string{"", "abc", "a.b.c.d", token + "x", "x" + token} {
+		_, err = VerifyInvite("secret", bad, now)
+		assert.ErrorIs(t, err, ErrInvalidInvite, bad)
+	}
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+// Config holds configuration of OIDC provider
+type Config struct {
+	Issuer       string   // issuer URL, login disabled when empty
+	ClientID     string   // OAuth client ID registered at the provider
+	ClientSecret string   // OAuth client secret
+	RedirectURL  string   // callback URL e.g https://retro.example.com/auth/callback
+	Scopes       []string // scopes to request in addition to openid
+}
+
+// Enabled returns whether OIDC login is configured
+func (c Config) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Identity holds claims of authenticated user
+type Identity struct {
+	Issuer            string `json:"iss"`
+	Subject           string `json:"sub"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	Picture           string `json:"picture"`
+}
+
+// User maps identity to user, ID is derived from issuer and subject so the same identity
+// is always the same user (across logins and instances).
+func (i Identity) User() models.User {
+	name := i.Name
+	if name == "" {
+		name = i.PreferredUsername
+	}
+	if name == "" {
+		name = i.Email
+	}
+	return models.User{
+		ID:            uuid.NewSHA1(uuid.NameSpaceURL, []byte(i.Issuer+"#"+i.Subject)),
+		Name:          name,
+		AvatarURL:     i.Picture,
+		Authenticated: true,
+	}
+}
+
+// OIDC authenticates users using authorization code flow (with PKCE) against OIDC provider
+type OIDC struct {
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// AuthCodeURL returns URL of provider login page.
+// state, nonce and verifier must be kept by the caller to complete the login in Exchange.
+func (o *OIDC) AuthCodeURL(state, nonce, verifier string) string {
+	return o.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange exchanges authorization code for ID token and returns identity from its claims
+func (o *OIDC) Exchange(ctx context.Context, code, nonce, verifier string) (*Identity, error) {
+	token, err := o.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := o.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("invalid id_token nonce")
+	}
+
+	var identity Identity
+	if err := idToken.Claims(&identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// NewOIDC creates OIDC authenticator, provider configuration is discovered from its issuer URL
+func NewOIDC(ctx context.Context, conf Config) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, conf.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	return &OIDC{
+		oauth: oauth2.Config{
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			RedirectURL:  conf.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, conf.Scopes...),
+		},
+		verifier: provider.Verifier(&oidc.Config{ClientID: conf.ClientID}),
+	}, nil
+}
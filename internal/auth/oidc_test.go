@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/auth/authtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestIdentity_User(t *testing.T) {
+	i := Identity{Issuer: "https://idp.example.com", Subject: "123", Name: "Alice", Email: "alice@example.com", Picture: "https://idp.example.com/alice.png"}
+	u := i.User()
+	assert.Equal(t, "Alice", u.Name)
+	assert.Equal(t, "https://idp.example.com/alice.png", u.AvatarURL)
+	assert.True(t, u.Authenticated)
+
+	// same identity always same user, other issuer is other user
+	assert.Equal(t, u.ID, i.User().ID)
+	other := i
+	other.Issuer = "https://other.example.com"
+	assert.NotEqual(t, u.ID, other.User().ID)
+
+	// name fallbacks
+	assert.Equal(t, "alice", Identity{PreferredUsername: "alice", Email: "alice@example.com"}.User().Name)
+	assert.Equal(t, "alice@example.com", Identity{Email: "alice@example.com"}.User().Name)
+}
+
+// authorize goes to provider login page and returns authorization code it redirects back with
+func authorize(t *testing.T, o *OIDC, state, nonce, verifier string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(o.AuthCodeURL(state, nonce, verifier))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/auth/callback", location.Path)
+	assert.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestOIDC(t *testing.T) {
+	idp := authtest.NewIdP("goretro")
+	defer idp.Close()
+	ctx := context.Background()
+
+	o, err := NewOIDC(ctx, Config{Issuer: idp.URL, ClientID: "goretro", ClientSecret: "secret", RedirectURL: "http://localhost/auth/callback"})
+	require.NoError(t, err)
+
+	// successful login
+	idp.Login(map[string]any{"sub": "alice-id", "name": "Alice", "picture": "https://idp.example.com/alice.png"})
+	verifier := oauth2.GenerateVerifier()
+	code := authorize(t, o, "state", "nonce", verifier)
+	identity, err := o.Exchange(ctx, code, "nonce", verifier)
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Issuer: idp.URL, Subject: "alice-id", Name: "Alice", Picture: "https://idp.example.com/alice.png"}, *identity)
+
+	// code can only be used once
+	_, err = o.Exchange(ctx, code, "nonce", verifier)
+	assert.Error(t, err)
+
+	// nonce must match the one sent on login
+	code = authorize(t, o, "state", "nonce", verifier)
+	_, err = o.Exchange(ctx, code, "other-nonce", verifier)
+	assert.ErrorContains(t, err, "nonce")
+
+	// PKCE verifier must match
+	code = authorize(t, o, "state", "nonce", verifier)
+	_, err = o.Exchange(ctx, code, "nonce", oauth2.GenerateVerifier())
+	assert.Error(t, err)
+}
+
+func TestNewOIDC_invalidIssuer(t *testing.T) {
+	idp := authtest.NewIdP("goretro")
+	defer idp.Close()
+
+	// issuer in discovery document must match
+	_, err := NewOIDC(context.Background(), Config{Issuer: idp.URL + "/other", ClientID: "goretro"})
+	assert.Error(t, err)
+}
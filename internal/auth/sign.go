@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// sign returns payload along with its signature.
+// Signature key is derived from the secret and the token context, so one kind of token can't be used as other kind.
+func sign(secret, context, payload string) string {
+	return payload + "." + signature(secret, context, payload)
+}
+
+// verify returns payload of signed token when its signature matches
+func verify(secret, context, token string) (string, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", false
+	}
+	payload, sig := token[:i], token[i+1:]
+	return payload, hmac.Equal([]byte(sig), []byte(signature(secret, context, payload)))
+}
+
+func signature(secret, context, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(context + "\n" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
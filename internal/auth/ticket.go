@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// context of websocket ticket signature
+const ticketContext = "goretro-ws-ticket-v1"
+
+var (
+	// ErrInvalidTicket returned when websocket ticket is malformed or its signature doesn't match
+	ErrInvalidTicket = errors.New("invalid ticket")
+
+	// ErrTicketExpired returned when websocket ticket is past its expiry time
+	ErrTicketExpired = errors.New("ticket expired")
+)
+
+// Ticket allows the user to open websocket connection to the board until it expires.
+// It's only given to the board page, so other sites can't connect on behalf of the user (cross-site websocket hijacking).
+type Ticket struct {
+	// ID is unique, the ticket can be used only once
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	BoardID   uuid.UUID
+	ExpiresAt time.Time
+}
+
+// SignTicket returns signed websocket ticket
+func SignTicket(secret string, ticket Ticket) string {
+	payload := fmt.Sprintf("%s.%s.%s.%d", ticket.ID, ticket.UserID, ticket.BoardID, ticket.ExpiresAt.Unix())
+	return sign(secret, ticketContext, payload)
+}
+
+// VerifyTicket verifies websocket ticket signature and expiry time
+func VerifyTicket(secret, token string, now time.Time) (*Ticket, error) {
+	payload, ok := verify(secret, ticketContext, token)
+	if !ok {
+		return nil, ErrInvalidTicket
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 {
+		return nil, ErrInvalidTicket
+	}
+	id, err := uuid.Parse(parts[0])
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+	userID, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+	boardID, err := uuid.Parse(parts[2])
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+	expires, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidTicket
+	}
+
+	ticket := &Ticket{ID: id, UserID: userID, BoardID: boardID, ExpiresAt: time.Unix(expires, 0)}
+	if !now.Before(ticket.ExpiresAt) {
+		return nil, ErrTicketExpired
+	}
+	return ticket, nil
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTicket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ticket := Ticket{ID: uuid.New(), UserID: uuid.New(), BoardID: uuid.New(), ExpiresAt: now.Add(time.Minute)}
+	token := SignTicket("secret", ticket)
+
+	got, err := VerifyTicket("secret", token, now)
+	require.NoError(t, err)
+	assert.Equal(t, ticket, *got)
+
+	_, err = VerifyTicket("secret", token, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrTicketExpired)
+
+	_, err = VerifyTicket("other-secret", token, now)
+	assert.ErrorIs(t, err, ErrInvalidTicket)
+
+	// invite can't be used as ticket, even with the same payload shape
+	invite := SignInvite("secret", Invite{BoardID: ticket.BoardID, Epoch: 1, ExpiresAt: ticket.ExpiresAt})
+	_, err = VerifyTicket("secret", invite, now)
+	assert.ErrorIs(t, err, ErrInvalidTicket)
+
+	for _, bad := range []string{"", "abc", "a.b.c.d.e", token + "x"} {
+		_, err = VerifyTicket("secret", bad, now)
+		assert.ErrorIs(t, err, ErrInvalidTicket, bad)
+	}
+}
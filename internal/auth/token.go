@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+)
+
+// prefix of API tokens, so they're easy to spot e.g by secret scanners
+const apiTokenPrefix = "grt_"
+
+var (
+	// ErrInvalidAPIToken returned when API token is malformed, unknown or its secret doesn't match
+	ErrInvalidAPIToken = errors.New("invalid api token")
+
+	// ErrAPITokenExpired returned when API token is past its expiry time
+	ErrAPITokenExpired = errors.New("api token expired")
+)
+
+// NewAPIToken returns new API token of the user along with its record to store.
+// The token contains user and token ID to look up its record, and a random secret of which only the hash is stored.
+func NewAPIToken(userID uuid.UUID, name string, now time.Time, ttl time.Duration) (string, models.APIToken) {
+	secret := rand.Text()
+	record := models.APIToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Hash:      hashSecret(secret),
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	return fmt.Sprintf("%s%s_%s_%s", apiTokenPrefix, userID, record.ID, secret), record
+}
+
+// ParseAPIToken returns user ID and token ID of API token, along with its secret
+func ParseAPIToken(token string) (userID, id uuid.UUID, secret string, err error) {
+	rest, ok := strings.CutPrefix(token, apiTokenPrefix)
+	if !ok {
+		return uuid.Nil, uuid.Nil, "", ErrInvalidAPIToken
+	}
+	parts := strings.Split(rest, "_")
+	if len(parts) != 3 || parts[2] == "" {
+		return uuid.Nil, uuid.Nil, "", ErrInvalidAPIToken
+	}
+	if userID, err = uuid.Parse(parts[0]); err != nil {
+		return uuid.Nil, uuid.Nil, "", ErrInvalidAPIToken
+	}
+	if id, err = uuid.Parse(parts[1]); err != nil {
+		return uuid.Nil, uuid.Nil, "", ErrInvalidAPIToken
+	}
+	return userID, id, parts[2], nil
+}
+
+// VerifyAPIToken verifies secret of API token against its stored record, and its expiry time
+func VerifyAPIToken(record *models.APIToken, secret string, now time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(record.Hash), []byte(hashSecret(secret))) != 1 {
+		return ErrInvalidAPIToken
+	}
+	if now.Unix() >= record.ExpiresAt {
+		return ErrAPITokenExpired
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
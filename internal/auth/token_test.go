@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIToken(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	userID := uuid.New()
+	token, record := NewAPIToken(userID, "ci", now, time.Hour)
+	assert.Equal(t, userID, record.UserID)
+	assert.NotContains(t, record.Hash, token)
+
+	gotUserID, id, secret, err := ParseAPIToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, gotUserID)
+	assert.Equal(t, record.ID, id)
+
+	assert.NoError(t, VerifyAPIToken(&record, secret, now))
+	assert.ErrorIs(t, VerifyAPIToken(&record, secret+"x", now), ErrInvalidAPIToken)
+	assert.ErrorIs(t, VerifyAPIToken(&record, secret, now.Add(time.Hour)), ErrAPITokenExpired)
+
+	for _, bad := range []string{"", "grt_", "abc", "grt_" + userID.String() + "_x_y", token[4:], token + "_x"} {
+		_, _, _, err := ParseAPIToken(bad)
+		assert.ErrorIs(t, err, ErrInvalidAPIToken, bad)
+	}
+}
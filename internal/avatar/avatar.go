@@ -0,0 +1,123 @@
+// Package avatar generates identicon avatars and resizes uploaded avatar images.
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	// decoders of accepted upload formats
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+const (
+	// Size is width and height of resized avatar, in pixels.
+	Size = 128
+
+	// Maximum width and height, and number of pixels of uploaded image, larger images are rejected before decoding.
+	// Decoded image takes up to 8 bytes per pixel, so the pixel limit keeps it within about 32MB.
+	maxUploadDimension = 4096
+	maxUploadPixels    = 2048 * 2048
+
+	// identicon grid cells in each row and column, left half is mirrored to the right.
+	gridSize = 5
+)
+
+// ErrInvalidImage returned when uploaded image can't be decoded or too large
+var ErrInvalidImage = errors.New("invalid image")
+
+// Identicon returns SVG identicon derived from seed (e.g user ID), same seed always gets the same avatar.
+func Identicon(seed []byte) []byte {
+	sum := sha256.Sum256(seed)
+
+	// color from first bytes, saturation and lightness are fixed so all avatars are equally readable
+	hue := (int(sum[0])<<8 | int(sum[1])) % 360
+	fg := fmt.Sprintf("hsl(%d,55%%,50%%)", hue)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`, gridSize+1, gridSize+1, Size, Size)
+	fmt.Fprint(&b, `<rect width="100%" height="100%" fill="#f0f0f0"/>`)
+	fmt.Fprintf(&b, `<g transform="translate(0.5 0.5)" fill="%s">`, fg)
+	half := (gridSize + 1) / 2
+	for y := range gridSize {
+		for x := range half {
+			// one bit per cell of the left half, from bytes not used for the color
+			bit := y*half + x
+			if sum[2+bit/8]&(1<<(bit%8)) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="1" height="1"/>`, x, y)
+			if mirror := gridSize - 1 - x; mirror != x {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="1" height="1"/>`, mirror, y)
+			}
+		}
+	}
+	b.WriteString(`</g></svg>`)
+	return b.Bytes()
+}
+
+// Resize decodes PNG, JPEG or GIF image then returns it center-cropped to a square and resized to Size, as PNG.
+// Re-encoding also drops anything other than the pixels e.g metadata.
+func Resize(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	conf, _, err := image.DecodeConfig(io.TeeReader(r, &buf))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImage, err.Error())
+	}
+	if conf.Width <= 0 || conf.Height <= 0 || conf.Width > maxUploadDimension || conf.Height > maxUploadDimension {
+		return nil, fmt.Errorf("%w: must be at most %dx%d pixels", ErrInvalidImage, maxUploadDimension, maxUploadDimension)
+	}
+	if conf.Width*conf.Height > maxUploadPixels {
+		return nil, fmt.Errorf("%w: must be at most %d megapixels", ErrInvalidImage, maxUploadPixels/(1<<20))
+	}
+	src, _, err := image.Decode(io.MultiReader(&buf, r))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidImage, err.Error())
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, scale(src, crop(src), Size)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// crop returns the largest centered square of the image bounds
+func crop(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// scale scales square area of src to size x size image, each pixel is the average of source pixels it covers
+func scale(src image.Image, area image.Rectangle, size int) image.Image {
+	dst := image.NewRGBA64(image.Rect(0, 0, size, size))
+	side := area.Dx()
+	for dy := range size {
+		y0 := area.Min.Y + dy*side/size
+		y1 := max(area.Min.Y+(dy+1)*side/size, y0+1)
+		for dx := range size {
+			x0 := area.Min.X + dx*side/size
+			x1 := max(area.Min.X+(dx+1)*side/size, x0+1)
+
+			var r, g, b, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(dx, dy, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
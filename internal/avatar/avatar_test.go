@@ -0,0 +1,74 @@
+package avatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdenticon(t *testing.T) {
+	a := Identicon([]byte("alice"))
+	assert.Equal(t, a, Identicon([]byte("alice")))
+	assert.NotEqual(t, a, Identicon([]byte("bob")))
+	assert.True(t, bytes.HasPrefix(a, []byte("<svg ")))
+	assert.True(t, bytes.HasSuffix(a, []byte("</svg>")))
+}
+
+func TestResize(t *testing.T) {
+	// wide image, left half red and right half blue, center crop keeps both
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := range 200 {
+		for x := range 400 {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	var in bytes.Buffer
+	require.NoError(t, jpeg.Encode(&in, src, nil))
+
+	out, err := Resize(&in)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, Size, Size), img.Bounds())
+
+	r, _, b, _ := img.At(10, Size/2).RGBA()
+	assert.Greater(t, r, b)
+	r, _, b, _ = img.At(Size-10, Size/2).RGBA()
+	assert.Greater(t, b, r)
+
+	// small image is scaled up
+	in.Reset()
+	require.NoError(t, png.Encode(&in, image.NewRGBA(image.Rect(0, 0, 3, 5))))
+	out, err = Resize(&in)
+	require.NoError(t, err)
+	img, err = png.Decode(bytes.NewReader(out))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, Size, Size), img.Bounds())
+}
+
+func TestResize_invalid(t *testing.T) {
+	_, err := Resize(bytes.NewReader([]byte("not an image")))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	// too large images are rejected without decoding them
+	var in bytes.Buffer
+	require.NoError(t, png.Encode(&in, image.NewGray(image.Rect(0, 0, maxUploadDimension+1, 1))))
+	_, err = Resize(&in)
+	assert.ErrorIs(t, err, ErrInvalidImage)
+
+	// as well as images within dimension limits but with too many pixels
+	in.Reset()
+	require.NoError(t, png.Encode(&in, image.NewGray(image.Rect(0, 0, maxUploadDimension, maxUploadDimension))))
+	_, err = Resize(&in)
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
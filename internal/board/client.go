@@ -3,17 +3,24 @@ package board
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"slices"
+	"sync"
 	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/ekaputra07/go-retro/internal/natsutil"
 	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -28,11 +35,21 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
+
+	// Time to wait before retrying to watch board changes after it failed.
+	rewatchInterval = time.Second
+
+	// Close code sent when the board is deleted, the UI won't reconnect (4000-4999 are for applications).
+	closeBoardDeleted = 4004
 )
 
+// errClientLeft is the cause of client context cancellation when client closed the connection
+var errClientLeft = errors.New("client left")
+
 // Client represents websocket connection between client (browser) that join a board
 type Client struct {
 	*models.Client
+	mu sync.Mutex // guards Client.User, it's replaced when user profile changed
 
 	logger     *slog.Logger
 	conn       *websocket.Conn
@@ -40,21 +57,74 @@ type Client struct {
 	store      *store.Store
 	msgHandler *messageHandler
 	messageCh  chan *nats.Msg
+
+	limiter      *limiter
+	boardLimiter *limiter
+	violations   violations
+
+	// spanContext of the request that opened the connection, linked from message spans
+	spanContext trace.SpanContext
 }
 
 // publish publish message to subscribers via nats
-func (c *Client) publish(topic string, msg any) {
+func (c *Client) publish(ctx context.Context, topic string, msg any) {
 	go func() {
+		ctx, span := tracing.Start(
+			ctx,
+			"nats.publish",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(attribute.String("messaging.destination.name", topic)),
+		)
 		data, err := json.Marshal(msg)
 		if err != nil {
 			c.logger.Error(fmt.Sprintf("failed marshaling message: %s", err.Error()))
+			tracing.End(span, err)
+			return
 		}
-		if err = c.nats.Conn.Publish(topic, data); err != nil {
+		m := &nats.Msg{Subject: topic, Data: data}
+		tracing.Inject(ctx, m)
+		if err = c.nats.Conn.PublishMsg(m); err != nil {
+			metrics.PublishFailures.WithLabelValues("client").Inc()
 			c.logger.Error(fmt.Sprintf("failed publishing message: %s", err.Error()))
 		}
+		tracing.End(span, err)
 	}()
 }
 
+// notify sends notification message only to this client
+func (c *Client) notify(ctx context.Context, text string) {
+	msg := message{
+		BoardID: c.BoardID,
+		Type:    messageTypeBoardNotification,
+		Data:    text,
+	}
+	data, err := msg.encode()
+	if err != nil {
+		c.logger.Error("failed to encode notification", "err", err.Error())
+		return
+	}
+	select {
+	case c.messageCh <- &nats.Msg{Data: data}:
+	case <-ctx.Done():
+	default:
+		// drop notification when client is not keeping up
+	}
+}
+
+// allow checks whether message of given type is within client and board rate limits
+func (c *Client) allow(t messageType) bool {
+	label := messageTypeLabel(t)
+	if !c.limiter.allow(t) {
+		metrics.ThrottledMessages.WithLabelValues("client", label).Inc()
+		return false
+	}
+	if !c.boardLimiter.allow(t) {
+		metrics.ThrottledMessages.WithLabelValues("board", label).Inc()
+		return false
+	}
+	return true
+}
+
 // checkTimerStateMessage check for latest state of active timer.
 // only return message when its in 'running' or 'paused' state.
 func (c *Client) checkTimerStateMessage() *message {
@@ -81,8 +151,9 @@ func (c *Client) checkTimerStateMessage() *message {
 	return nil
 }
 
-// read reads message from socket
-func (c *Client) read() {
+// read reads message from socket until the connection is closed.
+// Messages are handled within ctx, it's done once the writer stopped so nothing is sent to it anymore.
+func (c *Client) read(ctx context.Context) {
 	defer c.conn.Close()
 
 	c.conn.SetReadLimit(maxMessageSize)
@@ -99,81 +170,273 @@ func (c *Client) read() {
 			break
 		}
 
-		msg.User = *c.User
+		metrics.Messages.WithLabelValues(messageTypeLabel(msg.Type)).Inc()
+		if !c.allow(msg.Type) {
+			if c.violations.add(time.Now()) {
+				c.logger.Warn("client disconnected for exceeding rate limits", "id", c.ID)
+				metrics.ThrottledDisconnects.Inc()
+				closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded")
+				c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+				break
+			}
+			// only notify once per violation window
+			if c.violations.count == 1 {
+				c.notify(ctx, "You're doing that too fast, slow down!")
+			}
+			continue
+		}
+
+		if c.Spectator && !spectatorMessageTypes[msg.Type] {
+			metrics.SpectatorRejections.Inc()
+			c.notify(ctx, "You're watching this board, it can't be changed from here.")
+			continue
+		}
+
+		msg.User = c.user()
 		msg.BoardID = c.BoardID
 
-		switch msg.Type {
-		case messageTypeMe:
-			msgs := []message{msg}
+		// each message gets its own trace, linked to the connection trace
+		msgCtx, span := tracing.Start(
+			ctx,
+			fmt.Sprintf("ws.message %s", messageTypeLabel(msg.Type)),
+			trace.WithNewRoot(),
+			trace.WithLinks(trace.Link{SpanContext: c.spanContext}),
+			trace.WithAttributes(tracing.BoardID(c.BoardID.String()), attribute.String("goretro.client.id", c.ID.String())),
+		)
+		err = c.handleMessage(msgCtx, msg)
+		tracing.End(span, err)
 
-			// during ME inqury, check timer state and includes in messages if any.
-			// returning timer state to user is necessary so that new joined user could
-			// see the timer UI even when it's currently paused since paused timer don't emit events.
-			if timerStateMsg := c.checkTimerStateMessage(); timerStateMsg != nil {
-				msgs = append(msgs, *timerStateMsg)
+		if err != nil {
+			metrics.HandlerErrors.WithLabelValues(messageTypeLabel(msg.Type)).Inc()
+			c.logger.Error("client error handling message", "id", c.ID, "type", msg.Type, "err", err.Error())
+			if errors.Is(err, ErrBoardLimitReached) {
+				c.notify(ctx, fmt.Sprintf("Can't add more, %s!", err.Error()))
 			}
-
-			ml := newMessageList(c.BoardID, msgs...)
-			data, err := ml.encode()
-			if err != nil {
-				c.logger.Error("failed to encode messageList during ME", "err", err.Error())
+			if errors.Is(err, ErrNotAuthenticated) || errors.Is(err, ErrNotFacilitator) {
+				c.notify(ctx, "Sorry, "+err.Error()+".")
+			}
+			if errors.Is(err, ErrBoardReadOnly) {
+				c.notify(ctx, "Board is locked or archived, it can't be changed.")
 			}
-			c.messageCh <- &nats.Msg{Data: data}
-		case messageTypeTimerCmd:
-			c.publish(timerCmdTopic(c.BoardID), msg)
-		default:
-			c.msgHandler.handle(context.Background(), msg)
 		}
 	}
 }
 
-// write writes message to the socket
+// handleMessage handles single message received from socket
+func (c *Client) handleMessage(ctx context.Context, msg message) error {
+	switch msg.Type {
+	case messageTypeMe:
+		msgs := []message{msg}
+
+		// during ME inqury, check timer state and includes in messages if any.
+		// returning timer state to user is necessary so that new joined user could
+		// see the timer UI even when it's currently paused since paused timer don't emit events.
+		if timerStateMsg := c.checkTimerStateMessage(); timerStateMsg != nil {
+			msgs = append(msgs, *timerStateMsg)
+		}
+
+		ml := newMessageList(c.BoardID, msgs...)
+		data, err := ml.encode()
+		if err != nil {
+			return fmt.Errorf("failed to encode messageList during ME: %s", err.Error())
+		}
+		select {
+		case c.messageCh <- &nats.Msg{Data: data}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	case messageTypeTimerCmd:
+		if err := c.msgHandler.checkWritable(ctx, c.BoardID); err != nil {
+			return err
+		}
+		c.publish(ctx, timerCmdTopic(c.BoardID), msg)
+	case messageTypeUserUpdate:
+		// only the fields present in message are updated
+		var p Profile
+		var name string
+		if err := msg.stringVar(&name, "name"); err == nil {
+			p.Name = &name
+		}
+		var avatarID int
+		if err := msg.intVar(&avatarID, "avatar_id"); err == nil {
+			p.AvatarID = &avatarID
+		}
+		_, err := UpdateProfile(ctx, c.store, c.nats.Conn, msg.User.ID, p)
+		return err
+	default:
+		_, err := c.msgHandler.handle(ctx, msg)
+		return err
+	}
+	return nil
+}
+
+// user returns current user of the client
+func (c *Client) user() models.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.User
+}
+
+// updateUser replaces the user of the client when their profile changed,
+// then updates the client record so everyone on the board sees the change.
+func (c *Client) updateUser(ctx context.Context, msg *nats.Msg) {
+	var user models.User
+	if err := json.Unmarshal(msg.Data, &user); err != nil {
+		c.logger.Error("error decoding user update", "err", err.Error())
+		return
+	}
+	c.mu.Lock()
+	c.User = &user
+	record := *c.Client
+	c.mu.Unlock()
+
+	// client left, its record is being deleted
+	if ctx.Err() != nil {
+		return
+	}
+	if err := c.store.Clients.Create(ctx, record); err != nil {
+		c.logger.Error("error updating client record", "err", err.Error())
+	}
+	if c.Spectator {
+		return
+	}
+	if err := c.store.Participants.Put(ctx, c.BoardID, user); err != nil {
+		c.logger.Warn("error updating board participant", "err", err.Error())
+	}
+}
+
+// watch watches for board, clients, columns and cards changes.
+// When fromRevision is set, only changes since that revision are delivered, otherwise current values delivered first.
+func (c *Client) watch(ctx context.Context, fromRevision uint64) (jetstream.KeyWatcher, error) {
+	kv, err := c.nats.JS.KeyValue(ctx, "goretro")
+	if err != nil {
+		return nil, err
+	}
+	var opts []jetstream.WatchOpt
+	if fromRevision > 0 {
+		opts = append(opts, jetstream.ResumeFromRevision(fromRevision))
+	}
+	return kv.WatchFiltered(ctx, []string{
+		fmt.Sprintf("boards.%s", c.BoardID),
+		fmt.Sprintf("boards.%s.clients.*", c.BoardID),
+		fmt.Sprintf("boards.%s.columns.*", c.BoardID),
+		fmt.Sprintf("boards.%s.cards.*", c.BoardID),
+	}, opts...)
+}
+
+// resyncTimer requests current timer state into ch, as timer broadcasts sent while NATS was disconnected are lost.
+// The state is dropped when ctx is done, i.e writer no longer reads ch.
+func (c *Client) resyncTimer(ctx context.Context, ch chan<- *nats.Msg) {
+	msg, err := queryTimerStatus(c.nats.Conn, c.BoardID)
+	if errors.Is(err, nats.ErrNoResponders) {
+		return
+	}
+	if err != nil {
+		c.logger.Warn("error requesting timer status message", "err", err.Error())
+		return
+	}
+	select {
+	case ch <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// write writes message to the socket.
+// When NATS reconnected, the watch is re-established from the last seen revision so the socket stays open.
 func (c *Client) write(ctx context.Context) {
-	// subscribe for messages
+	// subscribe for messages, core subscription is restored on reconnect by nats client
 	messageSub, err := c.nats.Conn.ChanSubscribe(broadcastMessageTopic(c.BoardID), c.messageCh)
 	if err != nil {
 		c.logger.Error("client subscribe error -->", "id", c.ID, "err", err.Error())
 		return
 	}
 
-	// watch for clients, columns and cards changes
-	kv, err := c.nats.JS.KeyValue(ctx, "goretro")
+	userCh := make(chan *nats.Msg, 8)
+	userSub, err := c.nats.Conn.ChanSubscribe(userUpdatedTopic(c.user().ID), userCh)
 	if err != nil {
-		c.logger.Error("client kv error -->", "id", c.ID, "err", err.Error())
+		c.logger.Error("client subscribe error -->", "id", c.ID, "err", err.Error())
+		messageSub.Unsubscribe()
 		return
 	}
-	w, err := kv.WatchFiltered(ctx, []string{
-		fmt.Sprintf("boards.%s.clients.*", c.BoardID),
-		fmt.Sprintf("boards.%s.columns.*", c.BoardID),
-		fmt.Sprintf("boards.%s.cards.*", c.BoardID),
-	})
+
+	reconnected := c.nats.Reconnected()
+	w, err := c.watch(ctx, 0)
 	if err != nil {
 		c.logger.Error("client watch error -->", "id", c.ID, "err", err.Error())
+		messageSub.Unsubscribe()
+		userSub.Unsubscribe()
 		return
 	}
+	var lastRevision uint64
+	var rewatch <-chan time.Time
+	timerState := make(chan *nats.Msg, 1)
 
 	// pinger
 	ticker := time.NewTicker(pingPeriod)
 
 	defer func() {
 		messageSub.Unsubscribe()
-		w.Stop()
-		close(c.messageCh)
+		userSub.Unsubscribe()
+		if w != nil {
+			w.Stop()
+		}
 		ticker.Stop()
 		c.conn.Close()
 	}()
 
 	for {
 		select {
-		case kve := <-w.Updates():
+		case <-reconnected:
+			reconnected = c.nats.Reconnected()
+			if w != nil {
+				w.Stop()
+				w = nil
+			}
+			rewatch = time.After(0)
+			go c.resyncTimer(ctx, timerState)
+
+		case <-rewatch:
+			rewatch = nil
+			var from uint64
+			if lastRevision > 0 {
+				from = lastRevision + 1
+			}
+			if w, err = c.watch(ctx, from); err != nil {
+				// JetStream might not be ready yet right after reconnect
+				c.logger.Warn("client re-watch error, retrying -->", "id", c.ID, "err", err.Error())
+				w = nil
+				rewatch = time.After(rewatchInterval)
+			}
+
+		case kve, ok := <-watchUpdates(w):
+			if !ok {
+				// watcher closed underneath us, re-establish it
+				w = nil
+				rewatch = time.After(rewatchInterval)
+				continue
+			}
 			if kve != nil {
+				lastRevision = kve.Revision()
 				s, err := newStream(kve.Key(), kve.Operation(), kve.Value())
 				if s != nil && err == nil {
+					_, span := tracing.Start(ctx, "ws.stream", trace.WithAttributes(
+						tracing.BoardID(c.BoardID.String()),
+						attribute.String("goretro.stream.key", kve.Key()),
+						attribute.String("goretro.stream.op", s.Op),
+					))
 					c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-					if err := c.conn.WriteJSON(s); err != nil {
+					err := c.conn.WriteJSON(s)
+					tracing.End(span, err)
+					if err != nil {
 						c.logger.Error("client message error -->", "id", c.ID, "err", err.Error())
 						return
 					}
+					if s.Type == "board" && s.Op == "del" {
+						c.logger.Info("board deleted, closing client", "id", c.ID)
+						closeMsg := websocket.FormatCloseMessage(closeBoardDeleted, "board deleted")
+						c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+						return
+					}
 				}
 			}
 		case <-ticker.C:
@@ -183,39 +446,86 @@ func (c *Client) write(ctx context.Context) {
 				return
 			}
 		case msg := <-c.messageCh:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.TextMessage, msg.Data); err != nil {
-				c.logger.Error("client message error -->", "id", c.ID, "err", err.Error())
+			if err := c.deliver(ctx, msg); err != nil {
+				return
+			}
+		case msg := <-timerState:
+			if err := c.deliver(ctx, msg); err != nil {
 				return
 			}
+		case msg := <-userCh:
+			c.updateUser(ctx, msg)
 		case <-ctx.Done():
+			if !errors.Is(context.Cause(ctx), errClientLeft) {
+				// server is shutting down, tell the client so it can reconnect to other instance
+				closeMsg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting")
+				c.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+			}
 			return
 		}
 	}
 }
 
-// Start starts the client read (goroutine) and write (blocking) process
-func (c *Client) Start() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// deliver writes message received from NATS to the socket
+func (c *Client) deliver(ctx context.Context, msg *nats.Msg) error {
+	_, span := tracing.Start(
+		tracing.Extract(ctx, msg),
+		"ws.deliver",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(tracing.BoardID(c.BoardID.String())),
+	)
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	err := c.conn.WriteMessage(websocket.TextMessage, msg.Data)
+	tracing.End(span, err)
+	if err != nil {
+		c.logger.Error("client message error -->", "id", c.ID, "err", err.Error())
+	}
+	return err
+}
 
-	// start writer
-	go c.write(ctx)
+// watchUpdates returns updates channel of w, or nil channel (blocks forever) when there's no watcher
+func watchUpdates(w jetstream.KeyWatcher) <-chan jetstream.KeyValueEntry {
+	if w == nil {
+		return nil
+	}
+	return w.Updates()
+}
+
+// Start starts the client write (goroutine) and read (blocking) process.
+// When ctx is done (e.g server shutting down) the connection is closed with "server restarting" reason.
+func (c *Client) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(errClientLeft)
+
+	// start writer, the client is done once it stopped e.g on write error.
+	// messageCh is never closed, senders stop on ctx instead.
+	go func() {
+		c.write(ctx)
+		cancel(errClientLeft)
+	}()
 
 	defer func() {
+		// stop writer first so it won't re-create the client record e.g on user update
+		cancel(errClientLeft)
+
 		// delete client on leave
-		err := c.store.Clients.Delete(ctx, c.BoardID, c.ID)
+		deleteCtx, cancelDelete := context.WithTimeout(context.WithoutCancel(ctx), writeWait)
+		defer cancelDelete()
+		err := c.store.Clients.Delete(deleteCtx, c.BoardID, c.ID)
 		if err != nil {
 			c.logger.Error("error deleting client record", "board", c.BoardID, "id", c.ID)
 		}
+		limiters.release(c.BoardID)
+		metrics.ActiveClients.Dec()
 	}()
+	metrics.ActiveClients.Inc()
 	c.logger.Info("client started", "id", c.ID)
 
 	// read message from connection
-	c.read()
+	c.read(ctx)
 }
 
-// NewClient creates a new client instance
+// NewClient creates a new client instance, spectator client only watches the board
 func NewClient(
 	ctx context.Context,
 	conn *websocket.Conn,
@@ -224,22 +534,35 @@ func NewClient(
 	store *store.Store,
 	nats_ *natsutil.NATS,
 	boardID uuid.UUID,
+	spectator bool,
 ) (*Client, error) {
 	// create client record
 	model := models.NewClient(user, boardID)
+	model.Spectator = spectator
 	err := store.Clients.Create(ctx, model)
 	if err != nil {
 		return nil, err
 
 	}
+	// participants are kept for the board summary, it's not worth failing the connection for
+	if !spectator {
+		if err := store.Participants.Put(ctx, boardID, *user); err != nil {
+			logger.Warn("error adding board participant", "board_id", boardID, "err", err.Error())
+		}
+	}
 	// create client process instance
 	return &Client{
 		Client:     &model,
-		logger:     logger,
+		logger:     logger.With("board_id", boardID, "client_id", model.ID, "user_id", user.ID),
 		conn:       conn,
 		nats:       nats_,
 		store:      store,
-		msgHandler: newMessageHandler(store),
+		msgHandler: newMessageHandler(logger, store, nats_.Conn),
 		messageCh:  make(chan *nats.Msg, 256),
+
+		limiter:      newLimiter(clientRateLimits),
+		boardLimiter: limiters.acquire(boardID),
+
+		spanContext: trace.SpanContextFromContext(ctx),
 	}, nil
 }
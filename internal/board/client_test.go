@@ -0,0 +1,40 @@
+package board
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_send_afterWriterStopped(t *testing.T) {
+	srv := natstest.Server(t)
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	c := &Client{
+		Client:    &models.Client{},
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		nats:      &natsutil.NATS{Conn: nc},
+		messageCh: make(chan *nats.Msg, 1),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.notify(ctx, "first")
+	assert.Len(t, c.messageCh, 1)
+
+	// nobody reads messageCh once writer stopped, sending doesn't block nor panic
+	cancel()
+	assert.NotPanics(t, func() {
+		c.notify(ctx, "second")
+		err := c.handleMessage(ctx, message{Type: messageTypeMe})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,35 @@
+package board
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ekaputra07/go-retro/internal/metrics"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// EventTopics matches topic of all board events
+const EventTopics = "events.>"
+
+// eventTopic is where board event of given type published, e.g for webhooks
+func eventTopic(boardID uuid.UUID, typ string) string {
+	return "events." + boardID.String() + "." + typ
+}
+
+// publishEvent publishes board event. Nobody might be receiving it, so it's only published once and failure is not fatal.
+func publishEvent(ctx context.Context, conn *nats.Conn, e models.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	m := &nats.Msg{Subject: eventTopic(e.BoardID, e.Type), Data: data}
+	tracing.Inject(ctx, m)
+	if err := conn.PublishMsg(m); err != nil {
+		metrics.PublishFailures.WithLabelValues("event").Inc()
+		return err
+	}
+	return nil
+}
@@ -2,49 +2,272 @@ package board
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/tracing"
 	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Maximum number of columns a board can have.
+	// Column and card limits are soft, they're counted before creating so concurrent creations
+	// (e.g on other instances) may go slightly over. A counter on the board record would be exact,
+	// but columns and cards expire on their own so it would drift from the actual number.
+	maxColumnsPerBoard = 6
+
+	// Maximum number of cards a board can have, it's a soft limit like maxColumnsPerBoard.
+	maxCardsPerBoard = 300
+
+	// Maximum number of timer presets a board can have.
+	maxTimerPresets = 8
+
+	// Maximum timer warning in seconds.
+	maxTimerWarning = 3600
+
+	// How long to wait before purging a deleted board again,
+	// writes that passed checkWritable before the board was locked may land after the first purge.
+	deleteDrainDelay = 2 * time.Second
+)
+
+var (
+	// ErrBoardLimitReached returned when board already has maximum number of columns or cards.
+	ErrBoardLimitReached = errors.New("board limit reached")
+
+	// ErrNotAuthenticated returned when anonymous user tries what only authenticated user can do.
+	ErrNotAuthenticated = errors.New("only signed-in users can do that")
+
+	// ErrInvalidMessage returned when message data is missing or not valid.
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// ErrNotFacilitator returned when other than board facilitator tries to change board settings, lock, archive or delete the board.
+	ErrNotFacilitator = errors.New("only facilitator can do that")
+
+	// ErrBoardReadOnly returned when changing locked or archived board.
+	ErrBoardReadOnly = errors.New("board is read-only")
 )
 
 // messageHandler handles incoming message and operates on the store.
 type messageHandler struct {
-	store *store.Store
+	logger *slog.Logger
+	store  *store.Store
+	conn   *nats.Conn // to publish board events
 }
 
-func newMessageHandler(store *store.Store) *messageHandler {
-	return &messageHandler{store}
+func newMessageHandler(logger *slog.Logger, store *store.Store, conn *nats.Conn) *messageHandler {
+	return &messageHandler{logger, store, conn}
 }
 
-func (h *messageHandler) handle(ctx context.Context, msg message) error {
+// handle handles the message, it returns the record created or updated (nil if deleted)
+func (h *messageHandler) handle(ctx context.Context, msg message) (result any, err error) {
+	ctx, span := tracing.Start(
+		ctx,
+		fmt.Sprintf("board.handle %s", messageTypeLabel(msg.Type)),
+		trace.WithAttributes(
+			tracing.BoardID(msg.BoardID.String()),
+			attribute.String("goretro.user.id", msg.User.ID.String()),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	switch msg.Type {
+	case messageTypeBoardLock:
+		return h.lockBoard(ctx, msg)
+	case messageTypeBoardArchive:
+		return h.archiveBoard(ctx, msg)
+	case messageTypeBoardDelete:
+		return nil, h.deleteBoard(ctx, msg)
+	}
+
+	if err := h.checkWritable(ctx, msg.BoardID); err != nil {
+		return nil, err
+	}
+	switch msg.Type {
+	case messageTypeBoardUpdate:
+		return h.updateBoard(ctx, msg)
 	case messageTypeColumnNew:
 		return h.createColumn(ctx, msg)
 	case messageTypeColumnDelete:
-		return h.deleteColumn(ctx, msg)
+		return nil, h.deleteColumn(ctx, msg)
 	case messageTypeColumnUpdate:
 		return h.updateColumn(ctx, msg)
 	case messageTypeCardNew:
 		return h.createCard(ctx, msg)
 	case messageTypeCardDelete:
-		return h.deleteCard(ctx, msg)
+		return nil, h.deleteCard(ctx, msg)
 	case messageTypeCardUpdate:
 		return h.updateCard(ctx, msg)
 	case messageTypeCardVote:
 		return h.voteCard(ctx, msg)
 	}
-	return fmt.Errorf("message type=%s not supported by messageHandler", msg.Type)
+	return nil, fmt.Errorf("%w: message type=%s not supported by messageHandler", ErrInvalidMessage, msg.Type)
+}
+
+// checkWritable returns ErrBoardReadOnly when the board is locked or archived
+func (h *messageHandler) checkWritable(ctx context.Context, boardID uuid.UUID) error {
+	board, err := h.store.Boards.Get(ctx, boardID)
+	if err != nil {
+		return err
+	}
+	if board.ReadOnly() {
+		return ErrBoardReadOnly
+	}
+	return nil
 }
 
-func (h *messageHandler) createColumn(ctx context.Context, msg message) error {
+// updateFacilitatorBoard updates the board with mutate when message user is its facilitator
+func (h *messageHandler) updateFacilitatorBoard(ctx context.Context, msg message, mutate func(*models.Board) error) (*models.Board, error) {
+	return h.store.Boards.Update(ctx, msg.BoardID, func(board *models.Board) error {
+		if board.FacilitatorID != msg.User.ID {
+			return ErrNotFacilitator
+		}
+		return mutate(board)
+	})
+}
+
+// lockBoard locks or unlocks the board, nobody can change locked board.
+// Running timer is paused, as nobody can control it once the board is locked.
+func (h *messageHandler) lockBoard(ctx context.Context, msg message) (*models.Board, error) {
+	var locked bool
+	if err := msg.boolVar(&locked, "locked"); err != nil {
+		return nil, err
+	}
+	board, err := h.updateFacilitatorBoard(ctx, msg, func(board *models.Board) error {
+		board.Locked = locked
+		return nil
+	})
+	if err == nil && locked {
+		h.pauseTimer(msg)
+	}
+	return board, err
+}
+
+// archiveBoard archives or unarchives the board, archived board is read-only and hidden from board lists.
+// Running timer is paused like when the board is locked.
+func (h *messageHandler) archiveBoard(ctx context.Context, msg message) (*models.Board, error) {
+	var archived bool
+	if err := msg.boolVar(&archived, "archived"); err != nil {
+		return nil, err
+	}
+	board, err := h.updateFacilitatorBoard(ctx, msg, func(board *models.Board) error {
+		board.Archived = archived
+		return nil
+	})
+	if err == nil && archived {
+		h.pauseTimer(msg)
+	}
+	return board, err
+}
+
+// pauseTimer pauses timer of the board on behalf of the message user, it does nothing unless the timer is running
+func (h *messageHandler) pauseTimer(msg message) {
+	cmd := message{BoardID: msg.BoardID, Type: messageTypeTimerCmd, Data: timerCmd{Cmd: "pause"}, User: msg.User}
+	data, err := cmd.encode()
+	if err == nil {
+		err = h.conn.Publish(timerCmdTopic(msg.BoardID), data)
+	}
+	if err != nil {
+		h.logger.Warn("failed to pause timer", "board_id", msg.BoardID, "err", err.Error())
+	}
+}
+
+// deleteBoard deletes the board with all its records.
+// It's locked first so nothing is added to the board while its records are being deleted,
+// and purged again after deleteDrainDelay to catch writes that were already in flight.
+// Anything written even later expires with the bucket TTL, the tombstone keeps the board from coming back.
+func (h *messageHandler) deleteBoard(ctx context.Context, msg message) error {
+	board, err := h.updateFacilitatorBoard(ctx, msg, func(board *models.Board) error {
+		board.Locked = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := h.store.Boards.Delete(ctx, board.ID); err != nil {
+		return err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	time.AfterFunc(deleteDrainDelay, func() {
+		if err := h.store.Boards.Delete(ctx, board.ID); err != nil {
+			h.logger.Warn("failed to purge deleted board", "board_id", board.ID, "err", err.Error())
+		}
+	})
+	return nil
+}
+
+// updateBoard updates board settings, only the settings present in message are updated.
+// Only facilitator can change them, e.g participant requiring authentication would lock others out.
+func (h *messageHandler) updateBoard(ctx context.Context, msg message) (*models.Board, error) {
+	var presets []string
+	hasPresets := msg.stringsVar(&presets, "timer_presets") == nil
+	if hasPresets {
+		if len(presets) > maxTimerPresets {
+			return nil, fmt.Errorf("%w: maximum %d timer presets allowed", ErrInvalidMessage, maxTimerPresets)
+		}
+		for _, p := range presets {
+			if _, err := parseTimerDuration(p); err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidMessage, err.Error())
+			}
+		}
+	}
+
+	var warning int
+	hasWarning := msg.intVar(&warning, "timer_warning") == nil
+	if hasWarning && (warning < 0 || warning > maxTimerWarning) {
+		return nil, fmt.Errorf("%w: timer warning must be between 0 and %d seconds", ErrInvalidMessage, maxTimerWarning)
+	}
+
+	var autoAdvance bool
+	hasAutoAdvance := msg.boolVar(&autoAdvance, "timer_auto_advance") == nil
+
+	var requireAuth bool
+	hasRequireAuth := msg.boolVar(&requireAuth, "require_auth") == nil
+
+	return h.updateFacilitatorBoard(ctx, msg, func(board *models.Board) error {
+		// anonymous user can't require authentication, they would lock themselves out
+		if hasRequireAuth && requireAuth != board.RequireAuth {
+			if !msg.User.Authenticated {
+				return ErrNotAuthenticated
+			}
+			board.RequireAuth = requireAuth
+		}
+		if hasPresets {
+			board.TimerPresets = presets
+		}
+		if hasWarning {
+			board.TimerWarning = warning
+		}
+		if hasAutoAdvance {
+			board.TimerAutoAdvance = autoAdvance
+		}
+		return nil
+	})
+}
+
+func (h *messageHandler) createColumn(ctx context.Context, msg message) (*models.Column, error) {
 	var name string
 	if err := msg.stringVar(&name, "name"); err != nil {
-		return err
+		return nil, err
+	}
+	keys, err := h.store.Columns.ListKeys(ctx, msg.BoardID, maxColumnsPerBoard)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxColumnsPerBoard {
+		metrics.BoardLimitRejections.WithLabelValues("columns").Inc()
+		return nil, fmt.Errorf("%w: maximum %d columns", ErrBoardLimitReached, maxColumnsPerBoard)
 	}
 	col := models.NewColumn(name, msg.BoardID)
-	return h.store.Columns.Create(ctx, col)
+	return &col, h.store.Columns.Create(ctx, col)
 }
 
 func (h *messageHandler) deleteColumn(ctx context.Context, msg message) error {
@@ -55,15 +278,15 @@ func (h *messageHandler) deleteColumn(ctx context.Context, msg message) error {
 	return h.store.Columns.Delete(ctx, msg.BoardID, id)
 }
 
-func (h *messageHandler) updateColumn(ctx context.Context, msg message) error {
+func (h *messageHandler) updateColumn(ctx context.Context, msg message) (*models.Column, error) {
 	var id uuid.UUID
 	if err := msg.uuidVar(&id, "id"); err != nil {
-		return err
+		return nil, err
 	}
 
 	col, err := h.store.Columns.Get(ctx, msg.BoardID, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// if name set and new name is diff, update!
@@ -71,28 +294,48 @@ func (h *messageHandler) updateColumn(ctx context.Context, msg message) error {
 	if err := msg.stringVar(&name, "name"); err == nil {
 		if name != col.Name {
 			col.Name = name
-			return h.store.Columns.Update(ctx, *col)
+			return col, h.store.Columns.Update(ctx, *col)
 		}
 	}
-	return nil
+	return col, nil
 }
 
-func (h *messageHandler) createCard(ctx context.Context, msg message) error {
+func (h *messageHandler) createCard(ctx context.Context, msg message) (*models.Card, error) {
 	var name string
 	var columnID uuid.UUID
 
 	if err := msg.stringVar(&name, "name"); err != nil {
-		return err
+		return nil, err
 	}
 	if err := msg.uuidVar(&columnID, "column_id"); err != nil {
-		return err
+		return nil, err
 	}
 	col, err := h.store.Columns.Get(ctx, msg.BoardID, columnID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	keys, err := h.store.Cards.ListKeys(ctx, msg.BoardID, maxCardsPerBoard)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) >= maxCardsPerBoard {
+		metrics.BoardLimitRejections.WithLabelValues("cards").Inc()
+		return nil, fmt.Errorf("%w: maximum %d cards", ErrBoardLimitReached, maxCardsPerBoard)
 	}
 	card := models.NewCard(name, msg.BoardID, col.ID)
-	return h.store.Cards.Create(ctx, card)
+	if err := h.store.Cards.Create(ctx, card); err != nil {
+		return nil, err
+	}
+
+	typ := models.EventCardCreated
+	if col.IsActionItems() {
+		typ = models.EventActionItemCreated
+	}
+	event := models.NewEvent(typ, msg.BoardID, map[string]any{"card": card, "column": col})
+	if err := publishEvent(ctx, h.conn, event); err != nil {
+		h.logger.Warn("failed to publish card event", "board_id", msg.BoardID, "err", err.Error())
+	}
+	return &card, nil
 }
 
 func (h *messageHandler) deleteCard(ctx context.Context, msg message) error {
@@ -103,55 +346,46 @@ func (h *messageHandler) deleteCard(ctx context.Context, msg message) error {
 	return h.store.Cards.Delete(ctx, msg.BoardID, id)
 }
 
-func (h *messageHandler) updateCard(ctx context.Context, msg message) error {
+func (h *messageHandler) updateCard(ctx context.Context, msg message) (*models.Card, error) {
 	var id uuid.UUID
 	var name string
 	var columnID uuid.UUID
 
 	if err := msg.uuidVar(&id, "id"); err != nil {
-		return err
+		return nil, err
 	}
 
-	card, err := h.store.Cards.Get(ctx, msg.BoardID, id)
-	if err != nil {
-		return err
+	// name and column are updated when given, card can only be moved to column of the same board
+	hasName := msg.stringVar(&name, "name") == nil
+	hasColumn := msg.uuidVar(&columnID, "column_id") == nil
+	if hasColumn {
+		if _, err := h.store.Columns.Get(ctx, msg.BoardID, columnID); err != nil {
+			return nil, err
+		}
 	}
-
-	// update card name if new name given
-	if err := msg.stringVar(&name, "name"); err == nil {
-		if name != card.Name {
+	return h.store.Cards.Update(ctx, msg.BoardID, id, func(card *models.Card) error {
+		if hasName {
 			card.Name = name
 		}
-	}
-	// move to different column if new column_id given
-	if err := msg.uuidVar(&columnID, "column_id"); err == nil {
-		if columnID != card.ColumnID {
+		if hasColumn {
 			card.ColumnID = columnID
 		}
-	}
-	return h.store.Cards.Update(ctx, *card)
+		return nil
+	})
 }
 
-func (h *messageHandler) voteCard(ctx context.Context, msg message) error {
+func (h *messageHandler) voteCard(ctx context.Context, msg message) (*models.Card, error) {
 	var id uuid.UUID
 	var vote int
 
 	if err := msg.uuidVar(&id, "id"); err != nil {
-		return err
-	}
-
-	card, err := h.store.Cards.Get(ctx, msg.BoardID, id)
-	if err != nil {
-		return err
+		return nil, err
 	}
-
 	if err := msg.intVar(&vote, "vote"); err != nil {
-		return err
+		return nil, err
 	}
 	if vote != 1 && vote != -1 {
-		return fmt.Errorf("vote value of %v is invalid", vote)
+		return nil, fmt.Errorf("%w: vote value of %v is invalid", ErrInvalidMessage, vote)
 	}
-
-	card.Votes += vote
-	return h.store.Cards.Update(ctx, *card)
+	return h.store.Cards.Vote(ctx, msg.BoardID, id, vote)
 }
@@ -2,78 +2,320 @@ package board
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/ekaputra07/go-retro/internal/natsutil"
 	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
 )
 
+const (
+	// Queue group of timer handoff subscribers, so only one instance takes over the timer.
+	timerHandoffQueue = "goretro-timer-handoff"
+
+	// Maximum number of persisted timers checked for orphans on each scan.
+	maxOrphanScan = 1000
+
+	// How often persisted timers are scanned for orphans, timer of dead instance is taken over
+	// within LeaseTTL (its lease expired) plus this interval.
+	orphanScanInterval = 3 * store.LeaseTTL
+
+	// Maximum number of timers running on this instance.
+	maxTimersPerInstance = 5000
+)
+
+// errTooManyTimers returned when instance already runs maxTimersPerInstance timers.
+var errTooManyTimers = errors.New("too many timers")
+
+// ErrRateLimited returned when board receives messages faster than its rate limits.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
 // BoardManager provides apis to work with board and timer instances.
 type BoardManager struct {
+	id                  string // unique ID of this instance
 	logger              *slog.Logger
 	store               *store.Store
 	nats                *natsutil.NATS
-	timers              map[*timer]bool
 	initialBoardColumns []string
-	stopped             bool
+
+	// timers is the registry of timers running on this instance, keyed by board ID.
+	// Entry with nil timer reserves the board while its timer is being started.
+	// mu is never held during store or NATS calls.
+	mu       sync.Mutex
+	timers   map[uuid.UUID]*timer
+	starting sync.WaitGroup
+	stopped  atomic.Bool // set while holding mu, so no timer is reserved once it's set
 }
 
 // Healthy returns whether the manager is still running
 func (m *BoardManager) Healthy() bool {
-	return !m.stopped
+	return !m.stopped.Load()
 }
 
-// Start starts the board manager
+// Start starts the board manager and blocks until ctx is done.
+// While running, it takes over timers whose owner is gone.
+// Running timers then handed off to other instances before it returns.
 func (m *BoardManager) Start(ctx context.Context) {
-	m.logger.Info("board-manager running...")
+	handoffSub, err := m.nats.Conn.QueueSubscribe(timerHandoffTopics, timerHandoffQueue, m.takeOverTimer)
+	if err != nil {
+		m.logger.Error("board-manager failed to subscribe timer handoff topic", "err", err.Error())
+	}
+	m.logger.Info("board-manager running...", "id", m.id)
+
+	orphanTick := time.NewTicker(orphanScanInterval)
+	defer orphanTick.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-orphanTick.C:
+			m.takeOverOrphanTimers(ctx)
+		}
+	}
+
+	// stop taking over timers, otherwise our own timers could be handed back to us
+	if handoffSub != nil {
+		handoffSub.Unsubscribe()
+	}
+
+	m.mu.Lock()
+	m.stopped.Store(true)
+	m.mu.Unlock()
+
+	// no new timer can be started now, wait for the ones being started
+	m.starting.Wait()
+
+	m.mu.Lock()
+	timers := make([]*timer, 0, len(m.timers))
+	for _, t := range m.timers {
+		if t != nil {
+			timers = append(timers, t)
+		}
+	}
+	m.mu.Unlock()
 
-	<-ctx.Done()
 	m.logger.Info("Stopping all timers:")
-	for t := range m.timers {
+	for _, t := range timers {
 		m.logger.Info(fmt.Sprintf("stopping timer %s...", t.BoardID))
 		close(t.stopChan)
 	}
-	m.stopped = true
+	for _, t := range timers {
+		<-t.done
+	}
 	m.logger.Info("board-manager stopped")
 }
 
-// StartTimer starts the timer process for given boardID, returns true if new process started.
-func (m *BoardManager) StartTimer(boardID uuid.UUID) bool {
-	_, err := queryTimerStatus(m.nats.Conn, boardID)
+// takeOverTimer runs timer handed off by other instance
+func (m *BoardManager) takeOverTimer(msg *nats.Msg) {
+	var data struct {
+		BoardID uuid.UUID `json:"board_id"`
+	}
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		m.logger.Error("failed to decode timer handoff", "err", err.Error())
+		return
+	}
 
-	// if target timer not running anywhere, start new one
+	ok, err := m.runTimer(context.Background(), data.BoardID)
 	if err != nil {
-		timer := newTimer(boardID, m.nats, m.logger)
+		m.logger.Error("failed to take over timer", "id", data.BoardID, "err", err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	if err := msg.Respond([]byte("ok")); err != nil {
+		m.logger.Error("failed responding to timer handoff", "id", data.BoardID, "err", err.Error())
+	}
+	m.logger.Info("timer taken over", "id", data.BoardID)
+}
 
-		go timer.run()
-		m.timers[timer] = true
-		return true
+// takeOverOrphanTimers runs running or paused timers whose lease has expired (e.g owner instance died).
+// Only timers without lease are read, so the scan stays cheap while their owners are alive.
+func (m *BoardManager) takeOverOrphanTimers(ctx context.Context) {
+	leases, err := m.store.Leases.Keys(ctx)
+	if err != nil {
+		m.logger.Error("failed to list leases", "err", err.Error())
+		return
+	}
+	leased := make(map[string]bool, len(leases))
+	for _, key := range leases {
+		leased[key] = true
+	}
+	boardIDs, err := m.store.Timers.ListBoardIDs(ctx, maxOrphanScan)
+	if err != nil {
+		m.logger.Error("failed to list timers", "err", err.Error())
+		return
+	}
+	for _, boardID := range boardIDs {
+		if leased[timerLeaseKey(boardID)] {
+			continue
+		}
+		t, err := m.store.Timers.Get(ctx, boardID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue // deleted in the meantime
+		}
+		if err != nil {
+			m.logger.Error("failed to get timer", "id", boardID, "err", err.Error())
+			continue
+		}
+		if t.Status != string(timerStatusRunning) && t.Status != string(timerStatusPaused) {
+			continue
+		}
+		ok, err := m.runTimer(ctx, t.BoardID)
+		if err != nil {
+			m.logger.Error("failed to take over orphan timer", "id", t.BoardID, "err", err.Error())
+			continue
+		}
+		if ok {
+			m.logger.Info("orphan timer taken over", "id", t.BoardID)
+		}
 	}
-	return false
 }
 
-// GetOrCreateBoard get or creates board record
-func (m *BoardManager) GetOrCreateBoard(ctx context.Context, id uuid.UUID) (*models.Board, error) {
+// reserve reserves the board in the registry so its timer can be started,
+// returns false when the timer already running (or being started) on this instance.
+func (m *BoardManager) reserve(boardID uuid.UUID) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped.Load() {
+		return false, nil
+	}
+	if _, ok := m.timers[boardID]; ok {
+		return false, nil
+	}
+	if len(m.timers) >= maxTimersPerInstance {
+		metrics.TimerLimitRejections.Inc()
+		return false, fmt.Errorf("%w: maximum %d", errTooManyTimers, maxTimersPerInstance)
+	}
+	m.timers[boardID] = nil
+	m.starting.Add(1)
+	return true, nil
+}
+
+// runTimer acquires timer lease of given board and runs the timer, resuming its persisted state if any.
+// Returns false when the timer is owned by other instance (or this one).
+// The lease guarantees single timer per board across instances, while the registry
+// avoids hitting the store when the timer already running here.
+func (m *BoardManager) runTimer(ctx context.Context, boardID uuid.UUID) (ok bool, err error) {
+	reserved, err := m.reserve(boardID)
+	if !reserved {
+		return false, err
+	}
+
+	var t *timer
+	defer func() {
+		m.mu.Lock()
+		if ok {
+			m.timers[boardID] = t
+		} else {
+			delete(m.timers, boardID)
+		}
+		m.mu.Unlock()
+		m.starting.Done()
+		if ok {
+			go m.remove(t)
+		}
+	}()
+
+	rev, err := m.store.Leases.Acquire(ctx, timerLeaseKey(boardID), m.id)
+	if errors.Is(err, store.ErrLeaseTaken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	t = newTimer(boardID, m.id, m.nats, m.store, m.logger)
+	t.leaseRev = rev
+
+	release := func() {
+		if err := m.store.Leases.Release(ctx, timerLeaseKey(boardID), rev); err != nil {
+			m.logger.Error("failed to release timer lease", "id", boardID, "err", err.Error())
+		}
+	}
+	state, err := m.store.Timers.Get(ctx, boardID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		release()
+		return false, err
+	}
+	if state != nil {
+		t.restore(*state)
+	}
+	if err := t.start(); err != nil {
+		release()
+		return false, err
+	}
+	return true, nil
+}
+
+// remove removes timer from the registry once its process exited
+func (m *BoardManager) remove(t *timer) {
+	<-t.done
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timers[t.BoardID] == t {
+		delete(m.timers, t.BoardID)
+	}
+}
+
+// StartTimer starts the timer process for given boardID unless it's already running
+// on this or other instance, returns true if new process started.
+func (m *BoardManager) StartTimer(ctx context.Context, boardID uuid.UUID) bool {
+	ok, err := m.runTimer(ctx, boardID)
+	if err != nil {
+		m.logger.Error("failed to start timer", "id", boardID, "err", err.Error())
+		return false
+	}
+	return ok
+}
+
+// GetOrCreateBoard get or creates board record, user who creates the board becomes its facilitator.
+// New board starts with columns of given template, or the default columns when template is empty or unknown.
+// Deleted board isn't created again, store.ErrDeleted is returned.
+// When the board is created concurrently (e.g on other instance) the board that won is returned as is.
+func (m *BoardManager) GetOrCreateBoard(ctx context.Context, id uuid.UUID, user *models.User, template string) (*models.Board, error) {
 	b, err := m.store.Boards.Get(ctx, id)
 
 	if b != nil && err == nil {
 		// exist
 		m.logger.Info("board record exist", "id", id)
 		return b, nil
+	} else if !errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrDeleted) {
+		// don't overwrite existing board (e.g its access settings) on other errors, nor recreate deleted board
+		return nil, err
 	} else {
 		// not found? create new board record with their initial columns
 		nb := models.NewBoard(id)
+		nb.FacilitatorID = user.ID
 		err = m.store.Boards.Create(ctx, nb)
+		if errors.Is(err, store.ErrExists) {
+			// created by someone else in the meantime, along with its columns
+			return m.store.Boards.Get(ctx, id)
+		}
 		if err != nil {
 			return nil, err
 		}
 		m.logger.Info("board record created", "id", id)
+		if err := publishEvent(ctx, m.nats.Conn, models.NewEvent(models.EventBoardCreated, id, nb)); err != nil {
+			m.logger.Warn("failed to publish board created event", "id", id, "err", err.Error())
+		}
 
 		// create initial columns
-		for i, c := range m.initialBoardColumns {
+		columns, ok := templates[template]
+		if !ok {
+			columns = m.initialBoardColumns
+		}
+		for i, c := range columns {
 			col := models.NewColumn(c, id)
 			col.CreatedAt += int64(i) // alter created_at to keep order
 			err = m.store.Columns.Create(ctx, col)
@@ -86,14 +328,49 @@ func (m *BoardManager) GetOrCreateBoard(ctx context.Context, id uuid.UUID) (*mod
 	}
 }
 
+// Handle handles message of given type (e.g card.new) on behalf of the user, as if it's sent by their websocket client,
+// so changes are streamed to websocket clients of the board the same way. It returns the record created or updated.
+func (m *BoardManager) Handle(ctx context.Context, boardID uuid.UUID, user models.User, typ string, data map[string]any) (any, error) {
+	msg := message{BoardID: boardID, Type: messageType(typ), Data: data, User: user}
+	label := messageTypeLabel(msg.Type)
+	metrics.Messages.WithLabelValues(label).Inc()
+
+	boardLimiter := limiters.acquire(boardID)
+	defer limiters.release(boardID)
+	if !boardLimiter.allow(msg.Type) {
+		metrics.ThrottledMessages.WithLabelValues("board", label).Inc()
+		return nil, ErrRateLimited
+	}
+
+	result, err := newMessageHandler(m.logger, m.store, m.nats.Conn).handle(ctx, msg)
+	if err != nil {
+		metrics.HandlerErrors.WithLabelValues(label).Inc()
+		return nil, err
+	}
+	return result, nil
+}
+
+// Contents returns columns and cards of the board
+func (m *BoardManager) Contents(ctx context.Context, boardID uuid.UUID) ([]models.Column, []models.Card, error) {
+	columns, err := m.store.Columns.List(ctx, boardID, maxColumnsPerBoard)
+	if err != nil {
+		return nil, nil, err
+	}
+	cards, err := m.store.Cards.List(ctx, boardID, maxCardsPerBoard)
+	if err != nil {
+		return nil, nil, err
+	}
+	return columns, cards, nil
+}
+
 // NewBoardManager creates a new board manager instance
 func NewBoardManager(logger *slog.Logger, nats_ *natsutil.NATS, store *store.Store, initialcolumns []string) *BoardManager {
 	return &BoardManager{
+		id:                  uuid.NewString(),
 		logger:              logger,
 		nats:                nats_,
 		store:               store,
-		timers:              make(map[*timer]bool),
+		timers:              make(map[uuid.UUID]*timer),
 		initialBoardColumns: initialcolumns,
-		stopped:             false,
 	}
 }
@@ -0,0 +1,260 @@
+package board
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/metrics"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/store/natstore"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBoardManager creates board manager with its own connection to srv, as if it's on other instance
+func testBoardManager(t *testing.T, srv *server.Server) *BoardManager {
+	t.Helper()
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	n := &natsutil.NATS{Conn: nc, JS: js}
+	s, err := natstore.NewStore(context.Background(), n, "goretro")
+	require.NoError(t, err)
+	return NewBoardManager(slog.New(slog.NewTextHandler(io.Discard, nil)), n, s, nil)
+}
+
+// startBoardManager runs m until the test ends, returns func that stops it and waits until it's stopped
+func startBoardManager(t *testing.T, m *BoardManager) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Start(ctx)
+		close(done)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return stop
+}
+
+func Test_BoardManager_StartTimer_concurrent(t *testing.T) {
+	srv := natstest.Server(t)
+	managers := []*BoardManager{testBoardManager(t, srv), testBoardManager(t, srv)}
+	for _, m := range managers {
+		startBoardManager(t, m)
+	}
+
+	boardID := uuid.New()
+	var started atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if managers[i%2].StartTimer(context.Background(), boardID) {
+				started.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), started.Load())
+	running := 0
+	for _, m := range managers {
+		m.mu.Lock()
+		if t, ok := m.timers[boardID]; ok && t != nil {
+			running++
+		}
+		m.mu.Unlock()
+	}
+	assert.Equal(t, 1, running)
+}
+
+func Test_BoardManager_StartTimer_afterOwnerStopped(t *testing.T) {
+	srv := natstest.Server(t)
+	m1, m2 := testBoardManager(t, srv), testBoardManager(t, srv)
+	stop1 := startBoardManager(t, m1)
+	startBoardManager(t, m2)
+
+	boardID := uuid.New()
+	assert.True(t, m1.StartTimer(context.Background(), boardID))
+	assert.False(t, m1.StartTimer(context.Background(), boardID))
+	assert.False(t, m2.StartTimer(context.Background(), boardID))
+
+	// lease released on shutdown, so other instance can start it right away
+	stop1()
+	assert.True(t, m2.StartTimer(context.Background(), boardID))
+
+	m1.mu.Lock()
+	assert.Empty(t, m1.timers)
+	m1.mu.Unlock()
+}
+
+// sampleCount returns number of observations of the histogram
+func sampleCount(t *testing.T, o prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	require.NoError(t, o.(prometheus.Metric).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func Test_BoardManager_Handle_metrics(t *testing.T) {
+	srv := natstest.Server(t)
+	m := testBoardManager(t, srv)
+	ctx := context.Background()
+	user := models.NewUser(0)
+	b, err := m.GetOrCreateBoard(ctx, uuid.New(), &user, "")
+	require.NoError(t, err)
+
+	messages := metrics.Messages.WithLabelValues("column.new")
+	errs := metrics.HandlerErrors.WithLabelValues("column.new")
+	creates := metrics.StoreOperationDuration.WithLabelValues("columns", "Create")
+	messagesBefore, errsBefore, createsBefore := testutil.ToFloat64(messages), testutil.ToFloat64(errs), sampleCount(t, creates)
+
+	_, err = m.Handle(ctx, b.ID, user, "column.new", map[string]any{"name": "Ideas"})
+	require.NoError(t, err)
+	assert.Equal(t, messagesBefore+1, testutil.ToFloat64(messages))
+	assert.Equal(t, errsBefore, testutil.ToFloat64(errs))
+	assert.Equal(t, createsBefore+1, sampleCount(t, creates))
+
+	_, err = m.Handle(ctx, b.ID, user, "column.new", map[string]any{})
+	require.ErrorIs(t, err, ErrInvalidMessage)
+	assert.Equal(t, messagesBefore+2, testutil.ToFloat64(messages))
+	assert.Equal(t, errsBefore+1, testutil.ToFloat64(errs))
+	assert.Equal(t, createsBefore+1, sampleCount(t, creates))
+
+	// unknown types share one label
+	unknown := metrics.Messages.WithLabelValues("unknown")
+	unknownBefore := testutil.ToFloat64(unknown)
+	_, err = m.Handle(ctx, b.ID, user, "made.up", nil)
+	require.ErrorIs(t, err, ErrInvalidMessage)
+	assert.Equal(t, unknownBefore+1, testutil.ToFloat64(unknown))
+}
+
+func Test_BoardManager_takeOverOrphanTimers(t *testing.T) {
+	srv := natstest.Server(t)
+	m1, m2 := testBoardManager(t, srv), testBoardManager(t, srv)
+	startBoardManager(t, m1)
+	startBoardManager(t, m2)
+	ctx := context.Background()
+	running := func(boardID uuid.UUID) models.Timer {
+		return models.Timer{
+			BoardID:   boardID,
+			Status:    string(timerStatusRunning),
+			Duration:  time.Minute,
+			EndsAt:    time.Now().Add(time.Minute).UnixMilli(),
+			UpdatedAt: time.Now().Unix(),
+		}
+	}
+	owns := func(m *BoardManager, boardID uuid.UUID) bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.timers[boardID] != nil
+	}
+
+	// owner died and its lease expired, the timer is left running in the store
+	orphan := uuid.New()
+	require.NoError(t, m1.store.Timers.Put(ctx, running(orphan)))
+
+	// timer whose owner is alive
+	owned := uuid.New()
+	require.True(t, m1.StartTimer(ctx, owned))
+	require.NoError(t, m1.store.Timers.Put(ctx, running(owned)))
+
+	// stopped timer is left to be started by clients
+	stopped := uuid.New()
+	require.NoError(t, m1.store.Timers.Put(ctx, models.Timer{BoardID: stopped, Status: string(timerStatusStopped)}))
+
+	m2.takeOverOrphanTimers(ctx)
+	assert.True(t, owns(m2, orphan))
+	assert.False(t, owns(m2, owned))
+	assert.True(t, owns(m1, owned))
+	assert.False(t, owns(m2, stopped))
+
+	// lease now held by the new owner
+	_, err := m1.store.Leases.Acquire(ctx, timerLeaseKey(orphan), "other")
+	assert.ErrorIs(t, err, store.ErrLeaseTaken)
+	assert.False(t, m1.StartTimer(ctx, orphan))
+}
+
+func Test_BoardManager_Handle_deleteBoardPurgesLateWrites(t *testing.T) {
+	srv := natstest.Server(t)
+	m := testBoardManager(t, srv)
+	ctx := context.Background()
+	user := models.NewUser(0)
+	b, err := m.GetOrCreateBoard(ctx, uuid.New(), &user, "")
+	require.NoError(t, err)
+
+	_, err = m.Handle(ctx, b.ID, user, "board.delete", nil)
+	require.NoError(t, err)
+
+	// card write that passed the writable check before the board was locked
+	require.NoError(t, m.store.Cards.Create(ctx, models.NewCard("late", b.ID, uuid.New())))
+	cards, err := m.store.Cards.List(ctx, b.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+
+	require.Eventually(t, func() bool {
+		cards, err := m.store.Cards.List(ctx, b.ID, 10)
+		return err == nil && len(cards) == 0
+	}, 2*deleteDrainDelay, 100*time.Millisecond)
+	_, err = m.store.Boards.Get(ctx, b.ID)
+	assert.ErrorIs(t, err, store.ErrDeleted)
+}
+
+func Test_BoardManager_GetOrCreateBoard_concurrent(t *testing.T) {
+	srv := natstest.Server(t)
+	managers := []*BoardManager{testBoardManager(t, srv), testBoardManager(t, srv)}
+	ctx := context.Background()
+	id := uuid.New()
+
+	users := make([]models.User, 6)
+	boards := make([]*models.Board, len(users))
+	var wg sync.WaitGroup
+	for i := range users {
+		users[i] = models.NewUser(0)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := managers[i%2].GetOrCreateBoard(ctx, id, &users[i], "start-stop-continue")
+			assert.NoError(t, err)
+			boards[i] = b
+		}()
+	}
+	wg.Wait()
+
+	facilitatorID := boards[0].FacilitatorID
+	for _, b := range boards {
+		assert.Equal(t, facilitatorID, b.FacilitatorID)
+	}
+	columns, err := managers[0].store.Columns.List(ctx, id, 10)
+	require.NoError(t, err)
+	assert.Len(t, columns, len(templates["start-stop-continue"]))
+
+	// only the facilitator has the board indexed
+	for _, u := range users {
+		list, err := managers[0].store.Boards.ListByFacilitator(ctx, u.ID, 10)
+		require.NoError(t, err)
+		assert.Equal(t, u.ID == facilitatorID, len(list) == 1, "user %s", u.ID)
+	}
+}
@@ -19,9 +19,17 @@ type stream struct {
 }
 
 func newStream(key string, op jetstream.KeyValueOp, value []byte) (*stream, error) {
-	// key format: boards.<id>.<type>.<id>
+	// key format: boards.<id>.<type>.<id> or boards.<id> for the board itself
 	tokens := strings.Split(key, ".")
-	s := stream{Type: tokens[2], ID: tokens[3]}
+	var s stream
+	switch len(tokens) {
+	case 2:
+		s = stream{Type: "board", ID: tokens[1]}
+	case 4:
+		s = stream{Type: tokens[2], ID: tokens[3]}
+	default:
+		return nil, fmt.Errorf("stream key %s not supported", key)
+	}
 
 	switch op {
 	case jetstream.KeyValuePut:
@@ -35,6 +43,12 @@ func newStream(key string, op jetstream.KeyValueOp, value []byte) (*stream, erro
 	}
 
 	switch s.Type {
+	case "board":
+		var b models.Board
+		if err := json.Unmarshal(value, &b); err != nil {
+			return nil, err
+		}
+		s.Object = b
 	case "clients":
 		var c models.Client
 		if err := json.Unmarshal(value, &c); err != nil {
@@ -67,6 +81,10 @@ const (
 	messageTypeMe                messageType = "me"
 	messageTypeMessages          messageType = "messages"
 	messageTypeBoardNotification messageType = "board.notification"
+	messageTypeBoardUpdate       messageType = "board.update"
+	messageTypeBoardLock         messageType = "board.lock"
+	messageTypeBoardArchive      messageType = "board.archive"
+	messageTypeBoardDelete       messageType = "board.delete"
 	messageTypeColumnNew         messageType = "column.new"
 	messageTypeColumnUpdate      messageType = "column.update"
 	messageTypeColumnDelete      messageType = "column.delete"
@@ -76,8 +94,26 @@ const (
 	messageTypeCardVote          messageType = "card.vote"
 	messageTypeTimerCmd          messageType = "timer.cmd"
 	messageTypeTimerState        messageType = "timer.state"
+	messageTypeTimerWarning      messageType = "timer.warning"
+	messageTypeTimerDone         messageType = "timer.done"
+	messageTypeUserUpdate        messageType = "user.update"
 )
 
+// spectatorMessageTypes are message types spectators can send, none of them changes the board
+var spectatorMessageTypes = map[messageType]bool{
+	messageTypeMe:         true,
+	messageTypeUserUpdate: true,
+}
+
+// messageTypeLabel returns message type to be used as metric label,
+// only known message types are used to keep metric cardinality bounded.
+func messageTypeLabel(t messageType) string {
+	if _, ok := clientRateLimits[t]; !ok {
+		return "unknown"
+	}
+	return string(t)
+}
+
 // messageList is a type of message where it contains multiple messages in it.
 // this is to allow sending multiple messages at once.
 type messageList struct {
@@ -111,11 +147,11 @@ func (m message) encode() ([]byte, error) {
 func (m message) dataGet(key string) (any, error) {
 	data, ok := m.Data.(map[string]any)
 	if !ok {
-		return nil, fmt.Errorf("can't convert %+v to map[string]any", m.Data)
+		return nil, fmt.Errorf("%w: can't convert %+v to map[string]any", ErrInvalidMessage, m.Data)
 	}
 	val, ok := data[key]
 	if !ok {
-		return nil, fmt.Errorf("no key `%s` in %+v", key, data)
+		return nil, fmt.Errorf("%w: no key `%s` in %+v", ErrInvalidMessage, key, data)
 	}
 	return val, nil
 }
@@ -128,7 +164,7 @@ func (m message) stringVar(to *string, key string) error {
 	}
 	s, ok := val.(string)
 	if !ok {
-		return fmt.Errorf("couldn't convert %+v to string", val)
+		return fmt.Errorf("%w: couldn't convert %+v to string", ErrInvalidMessage, val)
 	}
 	*to = s
 	return nil
@@ -142,21 +178,61 @@ func (m message) intVar(to *int, key string) error {
 	}
 	f, ok := val.(float64)
 	if !ok {
-		return fmt.Errorf("couldn't convert %+v to float64", val)
+		return fmt.Errorf("%w: couldn't convert %+v to float64", ErrInvalidMessage, val)
 	}
 	*to = int(f)
 	return nil
 }
 
+// boolVar get bool value from data
+func (m message) boolVar(to *bool, key string) error {
+	val, err := m.dataGet(key)
+	if err != nil {
+		return err
+	}
+	b, ok := val.(bool)
+	if !ok {
+		return fmt.Errorf("%w: couldn't convert %+v to bool", ErrInvalidMessage, val)
+	}
+	*to = b
+	return nil
+}
+
+// stringsVar get list of string value from data
+func (m message) stringsVar(to *[]string, key string) error {
+	val, err := m.dataGet(key)
+	if err != nil {
+		return err
+	}
+	items, ok := val.([]any)
+	if !ok {
+		return fmt.Errorf("%w: couldn't convert %+v to []any", ErrInvalidMessage, val)
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return fmt.Errorf("%w: couldn't convert %+v to string", ErrInvalidMessage, item)
+		}
+		list = append(list, s)
+	}
+	*to = list
+	return nil
+}
+
 // uuidVar get UUID value from data
 func (m message) uuidVar(to *uuid.UUID, key string) error {
 	val, err := m.dataGet(key)
 	if err != nil {
 		return err
 	}
-	u, err := uuid.Parse(val.(string))
+	s, ok := val.(string)
+	if !ok {
+		return fmt.Errorf("%w: couldn't convert %+v to string", ErrInvalidMessage, val)
+	}
+	u, err := uuid.Parse(s)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %s", ErrInvalidMessage, err.Error())
 	}
 	*to = u
 	return nil
@@ -46,6 +46,26 @@ func Test_newStream_clients(t *testing.T) {
 	})
 }
 
+func Test_newStream_board(t *testing.T) {
+	t.Run("put op", func(t *testing.T) {
+		board := models.NewBoard(uuid.New())
+		val, _ := json.Marshal(board)
+
+		s, err := newStream("boards.b", jetstream.KeyValuePut, val)
+		assert.NoError(t, err)
+		assert.Equal(t, s.ID, "b")
+		assert.Equal(t, s.Op, "put")
+		assert.Equal(t, s.Type, "board")
+		assert.Equal(t, s.Object, board)
+	})
+
+	t.Run("unsupported key", func(t *testing.T) {
+		s, err := newStream("boards.b.timer", jetstream.KeyValuePut, nil)
+		assert.Error(t, err)
+		assert.Nil(t, s)
+	})
+}
+
 func Test_newStream_columns(t *testing.T) {
 	t.Run("delete op", func(t *testing.T) {
 		s, err := newStream("boards.b.columns.c", jetstream.KeyValueDelete, nil)
@@ -215,6 +235,54 @@ func Test_message_intVar(t *testing.T) {
 	})
 }
 
+func Test_message_boolVar(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		d := map[string]any{
+			"enabled": true,
+		}
+		var val bool
+		m := message{boardID, messageTypeBoardUpdate, d, models.NewUser(1)}
+		err := m.boolVar(&val, "enabled")
+		assert.NoError(t, err)
+		assert.True(t, val)
+	})
+
+	t.Run("infer error", func(t *testing.T) {
+		d := map[string]any{
+			"enabled": "yes",
+		}
+		var val bool
+		m := message{boardID, messageTypeBoardUpdate, d, models.NewUser(1)}
+		err := m.boolVar(&val, "enabled")
+		assert.Error(t, err)
+		assert.False(t, val)
+	})
+}
+
+func Test_message_stringsVar(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		d := map[string]any{
+			"presets": []any{"3m", "5m"},
+		}
+		var val []string
+		m := message{boardID, messageTypeBoardUpdate, d, models.NewUser(1)}
+		err := m.stringsVar(&val, "presets")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"3m", "5m"}, val)
+	})
+
+	t.Run("infer error", func(t *testing.T) {
+		d := map[string]any{
+			"presets": []any{"3m", 5},
+		}
+		var val []string
+		m := message{boardID, messageTypeBoardUpdate, d, models.NewUser(1)}
+		err := m.stringsVar(&val, "presets")
+		assert.Error(t, err)
+		assert.Nil(t, val)
+	})
+}
+
 func Test_message_uuidVar(t *testing.T) {
 	t.Run("ok", func(t *testing.T) {
 		id := uuid.New()
@@ -235,7 +303,18 @@ func Test_message_uuidVar(t *testing.T) {
 		var val uuid.UUID
 		m := message{boardID, messageTypeColumnNew, d, models.NewUser(1)}
 		err := m.uuidVar(&val, "id")
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+		assert.Equal(t, uuid.Nil, val)
+	})
+
+	t.Run("not string", func(t *testing.T) {
+		d := map[string]any{
+			"id": float64(123),
+		}
+		var val uuid.UUID
+		m := message{boardID, messageTypeColumnNew, d, models.NewUser(1)}
+		err := m.uuidVar(&val, "id")
+		assert.ErrorIs(t, err, ErrInvalidMessage)
 		assert.Equal(t, uuid.Nil, val)
 	})
 }
@@ -0,0 +1,94 @@
+package board
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// Maximum length of user name, in characters.
+const maxUserNameLength = 32
+
+var (
+	// ErrInvalidProfile returned when profile name or avatar is not valid
+	ErrInvalidProfile = errors.New("invalid profile")
+
+	// ErrProfileManaged returned when authenticated user tries to change their profile, it comes from the provider
+	ErrProfileManaged = errors.New("profile is managed by your sign-in provider")
+)
+
+// Profile holds user profile changes, nil fields are left unchanged
+type Profile struct {
+	Name     *string `json:"name"`
+	AvatarID *int    `json:"avatar_id"` // 0 is generated avatar, choosing avatar removes uploaded one
+
+	// AvatarURL of uploaded avatar, it can't be set by users directly
+	AvatarURL *string `json:"-"`
+}
+
+// ValidateUserName returns user name with surrounding and repeated spaces removed,
+// name must not be empty, too long or contain control characters.
+func ValidateUserName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidProfile)
+	}
+	if utf8.RuneCountInString(name) > maxUserNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrInvalidProfile, maxUserNameLength)
+	}
+	if strings.ContainsFunc(name, unicode.IsControl) {
+		return "", fmt.Errorf("%w: name must not contain control characters", ErrInvalidProfile)
+	}
+	return name, nil
+}
+
+// UpdateProfile updates user profile then notifies all clients of the user, on all boards and instances,
+// so they update their client record which everyone on the board is watching.
+func UpdateProfile(ctx context.Context, s *store.Store, conn *nats.Conn, userID uuid.UUID, p Profile) (*models.User, error) {
+	// changes are applied to the stored user, as the client's copy might be outdated
+	user, err := s.Users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Authenticated {
+		return nil, ErrProfileManaged
+	}
+	if p.Name != nil {
+		name, err := ValidateUserName(*p.Name)
+		if err != nil {
+			return nil, err
+		}
+		user.Name = name
+	}
+	if p.AvatarID != nil {
+		if *p.AvatarID < 0 || *p.AvatarID > models.AvatarsCount {
+			return nil, fmt.Errorf("%w: avatar must be between 0 and %d", ErrInvalidProfile, models.AvatarsCount)
+		}
+		user.AvatarID = *p.AvatarID
+		user.AvatarURL = ""
+	}
+	if p.AvatarURL != nil {
+		user.AvatarURL = *p.AvatarURL
+	}
+
+	if err := s.Users.Update(ctx, *user); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Publish(userUpdatedTopic(user.ID), data); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,35 @@
+package board
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUserName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"Alice", "Alice", true},
+		{"  Alice   Smith ", "Alice Smith", true},
+		{"Ålice 😉", "Ålice 😉", true},
+		{strings.Repeat("é", maxUserNameLength), strings.Repeat("é", maxUserNameLength), true},
+		{strings.Repeat("a", maxUserNameLength+1), "", false},
+		{"", "", false},
+		{" \t\n", "", false},
+		{"Alice\x00", "", false},
+		{"Alice\x7f", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ValidateUserName(tt.name)
+		if tt.ok {
+			assert.NoError(t, err, tt.name)
+		} else {
+			assert.ErrorIs(t, err, ErrInvalidProfile, tt.name)
+		}
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+}
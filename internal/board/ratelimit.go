@@ -0,0 +1,166 @@
+package board
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/metrics"
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Number of rate limit violations allowed within violationWindow before client get disconnected.
+	maxViolations = 20
+
+	// Window in which violations are counted.
+	violationWindow = 10 * time.Second
+)
+
+// rateLimit holds token bucket parameters
+type rateLimit struct {
+	rate  rate.Limit
+	burst int
+}
+
+// defaultRateLimit applied to message types that not listed in the limits.
+var defaultRateLimit = rateLimit{rate.Every(time.Second), 5}
+
+// otherMessageTypes is the bucket key of all message types not listed in the limits
+const otherMessageTypes messageType = "*"
+
+// clientRateLimits limits how fast a single client can send each type of message.
+var clientRateLimits = map[messageType]rateLimit{
+	messageTypeMe:           {rate.Every(time.Second), 5},
+	messageTypeBoardUpdate:  {rate.Every(time.Second), 3},
+	messageTypeBoardLock:    {rate.Every(time.Second), 3},
+	messageTypeBoardArchive: {rate.Every(time.Second), 3},
+	messageTypeBoardDelete:  {rate.Every(time.Second), 1},
+	messageTypeColumnNew:    {rate.Every(2 * time.Second), 3},
+	messageTypeColumnUpdate: {2, 5},
+	messageTypeColumnDelete: {rate.Every(2 * time.Second), 3},
+	messageTypeCardNew:      {1, 5},
+	messageTypeCardUpdate:   {3, 10},
+	messageTypeCardDelete:   {1, 5},
+	messageTypeCardVote:     {3, 10},
+	messageTypeTimerCmd:     {1, 3},
+	messageTypeUserUpdate:   {rate.Every(2 * time.Second), 3},
+}
+
+// boardRateLimits limits how fast all clients of a board combined can send each type of message.
+// Limits are applied per instance, so total throughput of a board is multiplied by number of instances.
+var boardRateLimits = map[messageType]rateLimit{
+	messageTypeMe:           {20, 50},
+	messageTypeBoardUpdate:  {1, 5},
+	messageTypeBoardLock:    {1, 5},
+	messageTypeBoardArchive: {1, 5},
+	messageTypeBoardDelete:  {1, 1},
+	messageTypeColumnNew:    {1, 5},
+	messageTypeColumnUpdate: {5, 10},
+	messageTypeColumnDelete: {1, 5},
+	messageTypeCardNew:      {10, 30},
+	messageTypeCardUpdate:   {20, 50},
+	messageTypeCardDelete:   {10, 30},
+	messageTypeCardVote:     {30, 100},
+	messageTypeTimerCmd:     {2, 5},
+	messageTypeUserUpdate:   {2, 10},
+}
+
+// limiter holds a token bucket for each message type
+type limiter struct {
+	mu      sync.Mutex
+	limits  map[messageType]rateLimit
+	buckets map[messageType]*rate.Limiter
+}
+
+// allow reports whether message of given type may be processed now
+func (l *limiter) allow(t messageType) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lim, ok := l.limits[t]
+	if !ok {
+		// unknown types share one bucket, so made-up types can't get around the limits
+		t, lim = otherMessageTypes, defaultRateLimit
+	}
+	b, ok := l.buckets[t]
+	if !ok {
+		b = rate.NewLimiter(lim.rate, lim.burst)
+		l.buckets[t] = b
+	}
+	return b.Allow()
+}
+
+func newLimiter(limits map[messageType]rateLimit) *limiter {
+	return &limiter{
+		limits:  limits,
+		buckets: make(map[messageType]*rate.Limiter),
+	}
+}
+
+// violations counts rate limit violations within violationWindow
+type violations struct {
+	count int
+	since time.Time
+}
+
+// add records a violation at given time, returns true when violations exceeded maxViolations.
+func (v *violations) add(now time.Time) bool {
+	if now.Sub(v.since) > violationWindow {
+		v.count = 0
+		v.since = now
+	}
+	v.count++
+	return v.count > maxViolations
+}
+
+// boardLimiter is shared limiter of a board with the number of clients using it
+type boardLimiter struct {
+	*limiter
+	refs int
+}
+
+// boardLimiters keeps board limiters shared by clients of the same board on this instance,
+// it's also used to track number of active boards.
+type boardLimiters struct {
+	mu     sync.Mutex
+	boards map[uuid.UUID]*boardLimiter
+}
+
+// acquire returns board limiter for given boardID, creates one if not exist
+func (bl *boardLimiters) acquire(boardID uuid.UUID) *limiter {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	b, ok := bl.boards[boardID]
+	if !ok {
+		b = &boardLimiter{limiter: newLimiter(boardRateLimits)}
+		bl.boards[boardID] = b
+		metrics.ActiveBoards.Inc()
+	}
+	b.refs++
+	return b.limiter
+}
+
+// release releases board limiter, removes it when no more clients using it
+func (bl *boardLimiters) release(boardID uuid.UUID) {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	b, ok := bl.boards[boardID]
+	if !ok {
+		return
+	}
+	b.refs--
+	if b.refs <= 0 {
+		delete(bl.boards, boardID)
+		metrics.ActiveBoards.Dec()
+	}
+}
+
+func newBoardLimiters() *boardLimiters {
+	return &boardLimiters{boards: make(map[uuid.UUID]*boardLimiter)}
+}
+
+// limiters is the board limiters registry of this instance
+var limiters = newBoardLimiters()
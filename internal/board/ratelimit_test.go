@@ -0,0 +1,86 @@
+package board
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_limiter_allow(t *testing.T) {
+	t.Run("within burst", func(t *testing.T) {
+		l := newLimiter(clientRateLimits)
+		burst := clientRateLimits[messageTypeCardNew].burst
+		for range burst {
+			assert.True(t, l.allow(messageTypeCardNew))
+		}
+		assert.False(t, l.allow(messageTypeCardNew))
+	})
+
+	t.Run("separate bucket per type", func(t *testing.T) {
+		l := newLimiter(clientRateLimits)
+		for l.allow(messageTypeCardNew) {
+		}
+		assert.True(t, l.allow(messageTypeCardVote))
+	})
+
+	t.Run("unknown type uses default", func(t *testing.T) {
+		l := newLimiter(clientRateLimits)
+		for range defaultRateLimit.burst {
+			assert.True(t, l.allow("unknown"))
+		}
+		assert.False(t, l.allow("unknown"))
+	})
+
+	t.Run("unknown types share a bucket", func(t *testing.T) {
+		l := newLimiter(clientRateLimits)
+		allowed := 0
+		for i := range 100 {
+			if l.allow(messageType(fmt.Sprint("unknown", i))) {
+				allowed++
+			}
+		}
+		assert.Equal(t, defaultRateLimit.burst, allowed)
+		assert.Len(t, l.buckets, 1)
+	})
+}
+
+func Test_violations_add(t *testing.T) {
+	t.Run("exceeded", func(t *testing.T) {
+		var v violations
+		now := time.Now()
+		for range maxViolations {
+			assert.False(t, v.add(now))
+		}
+		assert.True(t, v.add(now))
+	})
+
+	t.Run("reset after window", func(t *testing.T) {
+		var v violations
+		now := time.Now()
+		for range maxViolations {
+			v.add(now)
+		}
+		assert.False(t, v.add(now.Add(violationWindow+time.Second)))
+		assert.Equal(t, 1, v.count)
+	})
+}
+
+func Test_boardLimiters(t *testing.T) {
+	bl := newBoardLimiters()
+	id := uuid.New()
+
+	l1 := bl.acquire(id)
+	l2 := bl.acquire(id)
+	assert.Same(t, l1, l2)
+
+	bl.release(id)
+	assert.Len(t, bl.boards, 1)
+	bl.release(id)
+	assert.Len(t, bl.boards, 0)
+
+	// released board gets fresh limiter
+	assert.NotSame(t, l1, bl.acquire(id))
+}
@@ -0,0 +1,137 @@
+package board
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	// Maximum number of most voted cards in board summary.
+	maxTopCards = 5
+
+	// Maximum number of participants listed in board summary.
+	maxParticipants = 100
+)
+
+// Summary is the outcome of a board: its most voted cards, action items and statistics.
+type Summary struct {
+	Board       *models.Board
+	Columns     []ColumnStats // ordered by column creation time
+	TopCards    []models.Card // most voted cards that have votes, except action items
+	ActionItems []models.Card // ordered by votes
+	Votes       []VoteCount   // number of cards by their votes, most votes first
+	TotalCards  int
+	TotalVotes  int
+
+	Participants []models.User // users who joined the board, ordered by name
+	TimerUsedAt  int64         // last time the timer was used (unix seconds), 0 when never used
+}
+
+// ColumnStats is a column along with number of its cards and their votes
+type ColumnStats struct {
+	models.Column
+	Cards int
+	Votes int
+}
+
+// VoteCount is the number of cards that have the votes
+type VoteCount struct {
+	Votes int
+	Cards int
+}
+
+// Duration returns how long the retro took, from board creation to the last time the timer was used
+func (s *Summary) Duration() time.Duration {
+	if s.TimerUsedAt < s.Board.CreatedAt {
+		return 0
+	}
+	return time.Duration(s.TimerUsedAt-s.Board.CreatedAt) * time.Second
+}
+
+// ColumnName returns name of the column with given ID
+func (s *Summary) ColumnName(id uuid.UUID) string {
+	for _, c := range s.Columns {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// Summary returns summary of the board
+func (m *BoardManager) Summary(ctx context.Context, boardID uuid.UUID) (*Summary, error) {
+	b, err := m.store.Boards.Get(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	columns, cards, err := m.Contents(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	s := summarize(b, columns, cards)
+
+	if s.Participants, err = m.store.Participants.List(ctx, boardID, maxParticipants); err != nil {
+		return nil, fmt.Errorf("error listing participants: %w", err)
+	}
+	slices.SortFunc(s.Participants, func(x, y models.User) int {
+		return cmp.Compare(strings.ToLower(x.Name), strings.ToLower(y.Name))
+	})
+
+	// timer that never started has no starter
+	timer, err := m.store.Timers.Get(ctx, boardID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("error getting timer: %w", err)
+	}
+	if timer != nil && timer.StartedBy != nil {
+		s.TimerUsedAt = timer.UpdatedAt
+	}
+	return s, nil
+}
+
+func summarize(b *models.Board, columns []models.Column, cards []models.Card) *Summary {
+	columns = slices.Clone(columns)
+	slices.SortFunc(columns, func(x, y models.Column) int { return cmp.Compare(x.CreatedAt, y.CreatedAt) })
+	cards = slices.Clone(cards)
+	slices.SortStableFunc(cards, func(x, y models.Card) int {
+		return cmp.Or(cmp.Compare(y.Votes, x.Votes), cmp.Compare(x.CreatedAt, y.CreatedAt))
+	})
+
+	s := &Summary{Board: b, TotalCards: len(cards)}
+	stats := map[uuid.UUID]*ColumnStats{}
+	for _, c := range columns {
+		s.Columns = append(s.Columns, ColumnStats{Column: c})
+	}
+	for i := range s.Columns {
+		stats[s.Columns[i].ID] = &s.Columns[i]
+	}
+
+	for _, card := range cards {
+		col := stats[card.ColumnID]
+		if col != nil {
+			col.Cards++
+			col.Votes += card.Votes
+		}
+		s.TotalVotes += card.Votes
+		// cards are ordered by votes, so are the counts
+		if n := len(s.Votes); n == 0 || s.Votes[n-1].Votes != card.Votes {
+			s.Votes = append(s.Votes, VoteCount{Votes: card.Votes})
+		}
+		s.Votes[len(s.Votes)-1].Cards++
+
+		if col != nil && col.IsActionItems() {
+			s.ActionItems = append(s.ActionItems, card)
+		} else if card.Votes > 0 && len(s.TopCards) < maxTopCards {
+			s.TopCards = append(s.TopCards, card)
+		}
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package board
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	b := models.NewBoard(uuid.New())
+	good := models.NewColumn("Good", b.ID)
+	actions := models.NewColumn("Action items", b.ID)
+	actions.CreatedAt++
+
+	card := func(name string, col models.Column, votes int) models.Card {
+		c := models.NewCard(name, b.ID, col.ID)
+		c.Votes = votes
+		return c
+	}
+	cards := []models.Card{
+		card("no votes", good, 0),
+		card("fix ci", actions, 1),
+		card("pairing", good, 3),
+		card("write docs", actions, 2),
+	}
+	for i := range 6 {
+		cards = append(cards, card("nice", good, 1+i%2))
+	}
+
+	s := summarize(&b, []models.Column{actions, good}, cards)
+	assert.Equal(t, []ColumnStats{{Column: good, Cards: 8, Votes: 12}, {Column: actions, Cards: 2, Votes: 3}}, s.Columns)
+	assert.Equal(t, 10, s.TotalCards)
+	assert.Equal(t, 15, s.TotalVotes)
+	assert.Equal(t, []VoteCount{{Votes: 3, Cards: 1}, {Votes: 2, Cards: 4}, {Votes: 1, Cards: 4}, {Votes: 0, Cards: 1}}, s.Votes)
+	assert.Equal(t, "Action items", s.ColumnName(actions.ID))
+	assert.Equal(t, "", s.ColumnName(uuid.New()))
+
+	assert.Len(t, s.TopCards, maxTopCards)
+	assert.Equal(t, "pairing", s.TopCards[0].Name)
+	for _, c := range s.TopCards {
+		assert.Equal(t, good.ID, c.ColumnID)
+		assert.Positive(t, c.Votes)
+	}
+	assert.Equal(t, []string{"write docs", "fix ci"}, []string{s.ActionItems[0].Name, s.ActionItems[1].Name})
+}
+
+func TestSummaryDuration(t *testing.T) {
+	b := models.NewBoard(uuid.New())
+	s := &Summary{Board: &b}
+	assert.Equal(t, time.Duration(0), s.Duration())
+	s.TimerUsedAt = b.CreatedAt + 3900
+	assert.Equal(t, 65*time.Minute, s.Duration())
+}
+
+func Test_Column_IsActionItems(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Action items":    true,
+		"ACTIONS":         true,
+		"Next actions":    true,
+		"To-do/action":    true,
+		"Reactions":       false,
+		"Transactions":    false,
+		"Actionable":      false,
+		"What went well?": false,
+	} {
+		assert.Equal(t, want, models.Column{Name: name}.IsActionItems(), name)
+	}
+}
@@ -0,0 +1,25 @@
+package board
+
+import (
+	"maps"
+	"slices"
+)
+
+// templates are initial columns a new board can start with, instead of the default columns
+var templates = map[string][]string{
+	"start-stop-continue": {"Start", "Stop", "Continue", "Action items"},
+	"mad-sad-glad":        {"Mad", "Sad", "Glad", "Action items"},
+	"4ls":                 {"Liked", "Learned", "Lacked", "Longed for", "Action items"},
+	"sailboat":            {"Wind", "Anchors", "Rocks", "Island", "Action items"},
+}
+
+// TemplateNames returns names of board templates, sorted
+func TemplateNames() []string {
+	return slices.Sorted(maps.Keys(templates))
+}
+
+// ValidTemplate returns whether template with given name exists, empty name is the default columns
+func ValidTemplate(name string) bool {
+	_, ok := templates[name]
+	return ok || name == ""
+}
@@ -1,15 +1,23 @@
 package board
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/tracing"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type timerStatus string
@@ -30,24 +38,164 @@ func (tc *timerCmd) is(cmd string) bool {
 	return tc.Cmd == cmd
 }
 
+const (
+	// Time to wait for other instance to take over a timer.
+	handoffTimeout = 2 * time.Second
+
+	// How often timer lease is refreshed, must be less than store.LeaseTTL.
+	leaseRefreshInterval = store.LeaseTTL / 3
+
+	// Maximum duration of a timer.
+	maxTimerDuration = 24 * time.Hour
+
+	// How long a stopped (or done) timer kept running without clients before it exits.
+	timerIdleTimeout = 10 * time.Minute
+
+	// How often timer checks whether it's idle.
+	idleCheckInterval = time.Minute
+)
+
+// Reasons of timer process exit, used as metric label.
+const (
+	timerExitShutdown     = "shutdown"
+	timerExitLeaseLost    = "lease_lost"
+	timerExitIdle         = "idle"
+	timerExitBoardDeleted = "board_deleted"
+)
+
+// timerState is the timer state sent to clients.
+// Clients render the countdown locally from EndsAt, using ServerTime to compensate their clock skew.
+type timerState struct {
+	BoardID    uuid.UUID    `json:"board_id"`
+	Status     timerStatus  `json:"status"`
+	Duration   int64        `json:"duration"`    // milliseconds
+	EndsAt     int64        `json:"ends_at"`     // unix milliseconds, only set when running
+	Remaining  int64        `json:"remaining"`   // milliseconds
+	ServerTime int64        `json:"server_time"` // unix milliseconds
+	StartedBy  *models.User `json:"started_by"`
+}
+
+// timer counts down based on wall-clock end time, so it's not affected by how busy the process is.
 type timer struct {
-	BoardID uuid.UUID   `json:"board_id"`
-	Status  timerStatus `json:"status"`
-	Display string      `json:"display"`
+	BoardID uuid.UUID
+	Status  timerStatus
+
+	nats      *natsutil.NATS
+	store     *store.Store
+	duration  time.Duration
+	endsAt    time.Time     // only set when running
+	paused    time.Duration // remaining time when paused
+	startedBy *models.User
+	preset    string        // preset the timer started from, used to advance to the next one
+	warning   time.Duration // how long before done clients are warned
+	warned    bool          // whether clients already warned for current run
+	alarm     *time.Timer   // fires when running timer ends
+	warn      *time.Timer   // fires when running timer is about to end
+	changedAt time.Time     // last time the state changed (or the timer owned)
+	logger    *slog.Logger
+	cmdChan   chan *nats.Msg
+	stopChan  chan bool
+	done      chan struct{}
+
+	// owner is ID of the instance running the timer, and leaseRev is the
+	// revision of its lease that has to be refreshed to keep the ownership.
+	owner    string
+	leaseRev uint64
+}
 
-	nats     *natsutil.NATS
-	duration time.Duration
-	elapsed  time.Duration
-	logger   *slog.Logger
-	cmdChan  chan *nats.Msg
-	stopChan chan bool
+// parseTimerDuration parses timer duration e.g "5m", it must be positive and not exceed maxTimerDuration
+func parseTimerDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse timer duration: %s", err.Error())
+	}
+	if d <= 0 || d > maxTimerDuration {
+		return 0, fmt.Errorf("timer duration must be between 0 and %s", maxTimerDuration)
+	}
+	return d, nil
+}
+
+// nextPreset returns preset that comes after current one
+func nextPreset(presets []string, current string) (string, bool) {
+	if current == "" {
+		return "", false
+	}
+	i := slices.Index(presets, current)
+	if i < 0 || i+1 >= len(presets) {
+		return "", false
+	}
+	return presets[i+1], true
+}
+
+// remaining returns remaining time of the timer
+func (t *timer) remaining(now time.Time) time.Duration {
+	switch t.Status {
+	case timerStatusRunning:
+		return max(t.endsAt.Sub(now), 0)
+	case timerStatusPaused:
+		return t.paused
+	}
+	return 0
+}
+
+// schedule sets the alarms to fire when running timer is about to end and when it ends, or clears them otherwise.
+func (t *timer) schedule() {
+	if t.alarm != nil {
+		t.alarm.Stop()
+		t.alarm = nil
+	}
+	if t.warn != nil {
+		t.warn.Stop()
+		t.warn = nil
+	}
+	if t.Status != timerStatusRunning {
+		return
+	}
+	remaining := time.Until(t.endsAt)
+	t.alarm = time.NewTimer(remaining)
+	if t.warning > 0 && !t.warned {
+		t.warn = time.NewTimer(max(remaining-t.warning, 0))
+	}
+}
+
+// alarmC returns alarm channel, nil channel (blocks forever) when there's no alarm
+func (t *timer) alarmC() <-chan time.Time {
+	if t.alarm == nil {
+		return nil
+	}
+	return t.alarm.C
+}
+
+// warnC returns warning channel, nil channel (blocks forever) when there's no warning scheduled
+func (t *timer) warnC() <-chan time.Time {
+	if t.warn == nil {
+		return nil
+	}
+	return t.warn.C
 }
 
 func (t *timer) getStateMessage() message {
+	return t.getEventMessage(messageTypeTimerState)
+}
+
+// getEventMessage returns message of given type with current timer state as its data
+func (t *timer) getEventMessage(typ messageType) message {
+	now := time.Now()
+	state := timerState{
+		BoardID:    t.BoardID,
+		Status:     t.Status,
+		Duration:   t.duration.Milliseconds(),
+		Remaining:  t.remaining(now).Milliseconds(),
+		ServerTime: now.UnixMilli(),
+		StartedBy:  t.startedBy,
+	}
+	if t.Status == timerStatusRunning {
+		state.EndsAt = t.endsAt.UnixMilli()
+	}
 	return message{
 		BoardID: t.BoardID,
-		Type:    messageTypeTimerState,
-		Data:    t,
+		Type:    typ,
+		Data:    state,
 	}
 }
 
@@ -78,7 +226,7 @@ func (t *timer) parseCmd(msg message) (*timerCmd, error) {
 }
 
 // broadcast publish timer related message to board's broadcast channel/topic
-func (t *timer) broadcast(topic string, msgs ...message) error {
+func (t *timer) broadcast(ctx context.Context, topic string, msgs ...message) error {
 	if len(msgs) == 0 {
 		return fmt.Errorf("no message to broadcast")
 	}
@@ -98,52 +246,241 @@ func (t *timer) broadcast(topic string, msgs ...message) error {
 		}
 		msgJson = msg
 	}
-	return t.nats.Conn.Publish(topic, msgJson)
+	m := &nats.Msg{Subject: topic, Data: msgJson}
+	tracing.Inject(ctx, m)
+	if err := t.nats.Conn.PublishMsg(m); err != nil {
+		metrics.PublishFailures.WithLabelValues("timer").Inc()
+		return err
+	}
+	return nil
 }
 
-func (t *timer) updateDisplay() {
-	rem := t.duration - t.elapsed
-	m := int(rem.Minutes()) % 60
-	s := int(rem.Seconds()) % 60
-	t.Display = fmt.Sprintf("%02d:%02d", m, s)
+// state returns current state of the timer to be persisted
+func (t *timer) state() models.Timer {
+	s := models.Timer{
+		BoardID:   t.BoardID,
+		Status:    string(t.Status),
+		Duration:  t.duration,
+		StartedBy: t.startedBy,
+		Preset:    t.preset,
+		Warning:   t.warning,
+		UpdatedAt: time.Now().Unix(),
+	}
+	switch t.Status {
+	case timerStatusRunning:
+		s.EndsAt = t.endsAt.UnixMilli()
+	case timerStatusPaused:
+		s.Remaining = t.paused
+	}
+	return s
 }
 
-// run starts the timer process.
-// When started, it subscribe to timer command topic and react when new command received.
-func (t *timer) run() {
-	cmdSub, err := t.nats.Conn.ChanSubscribe(timerCmdTopic(t.BoardID), t.cmdChan)
+// restore restores timer from persisted state,
+// running timer continues from its end time, so time passed while nobody owned the timer is accounted.
+func (t *timer) restore(s models.Timer) {
+	t.Status = timerStatus(s.Status)
+	t.duration = s.Duration
+	t.startedBy = s.StartedBy
+	t.preset = s.Preset
+	t.warning = s.Warning
+
+	switch t.Status {
+	case timerStatusRunning:
+		t.endsAt = time.UnixMilli(s.EndsAt)
+		if !t.endsAt.After(time.Now()) {
+			t.Status = timerStatusDone
+			t.endsAt = time.Time{}
+		}
+	case timerStatusPaused:
+		t.paused = s.Remaining
+	}
+	// don't warn again when previous owner might have done it
+	t.warned = t.remaining(time.Now()) <= t.warning
+	t.schedule()
+}
+
+// save persists current state of the timer
+func (t *timer) save(ctx context.Context) error {
+	t.changedAt = time.Now()
+	if err := t.store.Timers.Put(ctx, t.state()); err != nil {
+		return fmt.Errorf("failed to save timer state: %s", err.Error())
+	}
+	return nil
+}
+
+// inactive reports whether the timer has been stopped (or done) for at least timerIdleTimeout
+func (t *timer) inactive(now time.Time) bool {
+	if t.Status == timerStatusRunning || t.Status == timerStatusPaused {
+		return false
+	}
+	return now.Sub(t.changedAt) >= timerIdleTimeout
+}
+
+// checkIdle returns the reason the timer process should exit, if any.
+// It should exit when its board is gone, or when it's inactive and no clients present on the board.
+func (t *timer) checkIdle(ctx context.Context) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, idleCheckInterval/2)
+	defer cancel()
+
+	if _, err := t.store.Boards.Get(ctx, t.BoardID); errors.Is(err, store.ErrNotFound) {
+		return timerExitBoardDeleted, true
+	}
+	if !t.inactive(time.Now()) {
+		return "", false
+	}
+	keys, err := t.store.Clients.ListKeys(ctx, t.BoardID, 1)
+	if err != nil {
+		t.logger.Error("failed to list board clients", "err", err.Error())
+		return "", false
+	}
+	return timerExitIdle, len(keys) == 0
+}
+
+// handoff hands the timer over to other instance, only running or paused timer handed off.
+// The state must be saved and lease released before handoff so the other instance can resume it.
+func (t *timer) handoff() {
+	if t.Status != timerStatusRunning && t.Status != timerStatusPaused {
+		return
+	}
+	data, err := json.Marshal(map[string]uuid.UUID{"board_id": t.BoardID})
 	if err != nil {
-		t.logger.Error("timer failed to subscribe cmd topic", "id", t.BoardID, "err", err.Error())
+		t.logger.Error("failed to encode timer handoff", "err", err.Error())
 		return
 	}
+	if _, err = t.nats.Conn.Request(timerHandoffTopic(t.BoardID), data, handoffTimeout); err != nil {
+		t.logger.Warn("no instance took over the timer, it will be resumed when lease expired", "err", err.Error())
+		return
+	}
+	t.logger.Info("timer handed off")
+}
+
+// stop saves the timer state and releases its lease
+func (t *timer) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+
+	if err := t.save(ctx); err != nil {
+		t.logger.Error(err.Error())
+	}
+	t.release(ctx)
+}
+
+// release releases timer lease
+func (t *timer) release(ctx context.Context) {
+	if err := t.store.Leases.Release(ctx, timerLeaseKey(t.BoardID), t.leaseRev); err != nil {
+		t.logger.Error("failed to release timer lease", "err", err.Error())
+	}
+}
+
+// exit cleans up after idle timer, its state is removed along with the board
+func (t *timer) exit(reason string) {
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+
+	if reason == timerExitBoardDeleted {
+		if err := t.store.Timers.Delete(ctx, t.BoardID); err != nil {
+			t.logger.Error("failed to delete timer state", "err", err.Error())
+		}
+	}
+	t.release(ctx)
+}
+
+// refreshLease refreshes timer lease to keep the ownership
+func (t *timer) refreshLease() error {
+	ctx, cancel := context.WithTimeout(context.Background(), leaseRefreshInterval)
+	defer cancel()
 
-	tick := time.NewTicker(1 * time.Second)
+	rev, err := t.store.Leases.Refresh(ctx, timerLeaseKey(t.BoardID), t.owner, t.leaseRev)
+	if err != nil {
+		return err
+	}
+	t.leaseRev = rev
+	return nil
+}
+
+// start subscribes to timer command topic and runs the timer process in background.
+// Subscription is flushed to the server before returning so the timer is ready to receive commands.
+func (t *timer) start() error {
+	cmdSub, err := t.nats.Conn.ChanSubscribe(timerCmdTopic(t.BoardID), t.cmdChan)
+	if err != nil {
+		return err
+	}
+	if err = t.nats.Conn.FlushTimeout(handoffTimeout); err != nil {
+		cmdSub.Unsubscribe()
+		return err
+	}
+	go t.run(cmdSub)
+	return nil
+}
+
+// run runs the timer process, reacts when new command received and keeps the lease refreshed.
+// When stop signal received, the timer is handed off to other instance.
+// It exits by itself when idle, see checkIdle.
+func (t *timer) run(cmdSub *nats.Subscription) {
+	leaseTick := time.NewTicker(leaseRefreshInterval)
+	idleTick := time.NewTicker(idleCheckInterval)
+	var reason string
 
 	defer func() {
 		cmdSub.Unsubscribe()
 		close(t.cmdChan)
-		tick.Stop()
-		t.logger.Info("timer stopped")
+		if t.alarm != nil {
+			t.alarm.Stop()
+		}
+		if t.warn != nil {
+			t.warn.Stop()
+		}
+		leaseTick.Stop()
+		idleTick.Stop()
+		metrics.ActiveTimers.Dec()
+		metrics.TimerExits.WithLabelValues(reason).Inc()
+		t.logger.Info("timer stopped", "reason", reason)
+		close(t.done)
 	}()
 
+	metrics.ActiveTimers.Inc()
 	t.logger.Info("timer started")
 	for {
 		select {
 		case <-t.stopChan:
 			t.logger.Info("timer stop signal received")
+			reason = timerExitShutdown
+			// stop receiving commands before handing off, so there's only one timer answering at a time
+			cmdSub.Unsubscribe()
+			t.stop()
+			t.handoff()
 			return
 
-		case <-tick.C:
-			if t.Status == timerStatusRunning {
-				t.elapsed += 1 * time.Second
-
-				// done
-				if (t.duration - t.elapsed) == 0 {
-					t.Status = timerStatusDone
-					t.logger.Info("timer done")
-				}
-				t.updateDisplay()
-				t.broadcast(broadcastMessageTopic(t.BoardID), t.getStateMessage())
+		case <-leaseTick.C:
+			err := t.refreshLease()
+			if errors.Is(err, store.ErrLeaseTaken) {
+				// other instance owns the timer now (or lease expired while disconnected), stop without touching its state
+				t.logger.Warn("timer lease lost", "err", err.Error())
+				reason = timerExitLeaseLost
+				return
+			}
+			if err != nil {
+				// e.g NATS is reconnecting, keep running and retry on next tick
+				t.logger.Warn("failed to refresh timer lease", "err", err.Error())
+			}
+
+		case <-idleTick.C:
+			if r, ok := t.checkIdle(context.Background()); ok {
+				reason = r
+				cmdSub.Unsubscribe()
+				t.exit(reason)
+				return
+			}
+
+		case <-t.warnC():
+			t.warn = nil
+			t.warned = true
+			t.broadcast(context.Background(), broadcastMessageTopic(t.BoardID), t.getEventMessage(messageTypeTimerWarning))
+
+		case <-t.alarmC():
+			t.alarm = nil
+			if err := t.finish(context.Background()); err != nil {
+				t.logger.Error(err.Error())
 			}
 
 		case msg := <-t.cmdChan:
@@ -158,7 +495,16 @@ func (t *timer) run() {
 				t.logger.Error("failed parsing timer cmd", "err", err.Error())
 				continue
 			}
-			if err = t.handleCommand(msg, cmd, m.User); err != nil {
+			ctx, span := tracing.Start(
+				tracing.Extract(context.Background(), msg),
+				"timer.cmd",
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(tracing.BoardID(t.BoardID.String()), attribute.String("goretro.timer.cmd", cmd.Cmd)),
+			)
+			err = t.handleCommand(ctx, msg, cmd, m.User)
+			tracing.End(span, err)
+			if err != nil {
+				metrics.HandlerErrors.WithLabelValues(string(messageTypeTimerCmd)).Inc()
 				t.logger.Error("failed handling timer cmd", "err", err.Error())
 				continue
 			}
@@ -167,7 +513,7 @@ func (t *timer) run() {
 }
 
 // handleCommand handles each command
-func (t *timer) handleCommand(nmsg *nats.Msg, cmd *timerCmd, user models.User) error {
+func (t *timer) handleCommand(ctx context.Context, nmsg *nats.Msg, cmd *timerCmd, user models.User) error {
 	switch {
 	case cmd.is("status"):
 		stateMsg := t.getStateMessage()
@@ -180,18 +526,26 @@ func (t *timer) handleCommand(nmsg *nats.Msg, cmd *timerCmd, user models.User) e
 		}
 
 	case cmd.is("start") && (t.Status == timerStatusStopped || t.Status == timerStatusDone):
-		d, err := time.ParseDuration(cmd.Value)
+		d, err := parseTimerDuration(cmd.Value)
 		if err != nil {
-			return fmt.Errorf("unable to parse timer duration: %s", err.Error())
+			return err
+		}
+		board, err := t.store.Boards.Get(ctx, t.BoardID)
+		if err != nil {
+			return fmt.Errorf("failed to get board: %s", err.Error())
+		}
+		preset := ""
+		if slices.Contains(board.TimerPresets, cmd.Value) {
+			preset = cmd.Value
+		}
+		if err = t.begin(ctx, d, preset, time.Duration(board.TimerWarning)*time.Second, &user); err != nil {
+			return err
 		}
-		t.duration = d
-		t.elapsed = 0
-		t.Status = timerStatusRunning
-		t.updateDisplay()
 
 		statusMessage := fmt.Sprintf("%s started the timer", user.Name)
 		t.logger.Info("timer running")
 		return t.broadcast(
+			ctx,
 			broadcastMessageTopic(t.BoardID),
 			t.getStateMessage(),
 			t.getNotificationMessage(statusMessage, user),
@@ -199,10 +553,17 @@ func (t *timer) handleCommand(nmsg *nats.Msg, cmd *timerCmd, user models.User) e
 
 	case cmd.is("start") && t.Status == timerStatusPaused:
 		t.Status = timerStatusRunning
+		t.endsAt = time.Now().Add(t.paused)
+		t.paused = 0
+		t.schedule()
+		if err := t.save(ctx); err != nil {
+			return err
+		}
 
 		statusMessage := fmt.Sprintf("%s resumed the timer", user.Name)
 		t.logger.Info("timer resumed")
 		return t.broadcast(
+			ctx,
 			broadcastMessageTopic(t.BoardID),
 			t.getStateMessage(),
 			t.getNotificationMessage(statusMessage, user),
@@ -211,22 +572,62 @@ func (t *timer) handleCommand(nmsg *nats.Msg, cmd *timerCmd, user models.User) e
 	case cmd.is("stop"):
 		t.Status = timerStatusStopped
 		t.duration = 0
-		t.elapsed = 0
+		t.endsAt = time.Time{}
+		t.paused = 0
+		t.startedBy = nil
+		t.schedule()
+		if err := t.save(ctx); err != nil {
+			return err
+		}
 
 		statusMessage := fmt.Sprintf("%s stopped the timer", user.Name)
 		t.logger.Info("timer stopped")
 		return t.broadcast(
+			ctx,
 			broadcastMessageTopic(t.BoardID),
 			t.getStateMessage(),
 			t.getNotificationMessage(statusMessage, user),
 		)
 
-	case cmd.is("pause"):
+	case (cmd.is("add") || cmd.is("subtract")) && (t.Status == timerStatusRunning || t.Status == timerStatusPaused):
+		d, err := parseTimerDuration(cmd.Value)
+		if err != nil {
+			return err
+		}
+		statusMessage := fmt.Sprintf("%s added %s to the timer", user.Name, d)
+		if cmd.is("subtract") {
+			d = -d
+			statusMessage = fmt.Sprintf("%s took %s off the timer", user.Name, -d)
+		}
+		if err = t.extend(d, time.Now()); err != nil {
+			return err
+		}
+		t.schedule()
+		if err = t.save(ctx); err != nil {
+			return err
+		}
+
+		t.logger.Info("timer extended", "by", d)
+		return t.broadcast(
+			ctx,
+			broadcastMessageTopic(t.BoardID),
+			t.getStateMessage(),
+			t.getNotificationMessage(statusMessage, user),
+		)
+
+	case cmd.is("pause") && t.Status == timerStatusRunning:
+		t.paused = t.remaining(time.Now())
+		t.endsAt = time.Time{}
 		t.Status = timerStatusPaused
+		t.schedule()
+		if err := t.save(ctx); err != nil {
+			return err
+		}
 
 		statusMessage := fmt.Sprintf("%s paused the timer", user.Name)
 		t.logger.Info("timer paused")
 		return t.broadcast(
+			ctx,
 			broadcastMessageTopic(t.BoardID),
 			t.getStateMessage(),
 			t.getNotificationMessage(statusMessage, user),
@@ -235,14 +636,107 @@ func (t *timer) handleCommand(nmsg *nats.Msg, cmd *timerCmd, user models.User) e
 	return nil
 }
 
-func newTimer(boardID uuid.UUID, nats_ *natsutil.NATS, logger *slog.Logger) *timer {
+// begin starts the timer with given duration
+func (t *timer) begin(ctx context.Context, d time.Duration, preset string, warning time.Duration, user *models.User) error {
+	t.duration = d
+	t.endsAt = time.Now().Add(d)
+	t.paused = 0
+	t.Status = timerStatusRunning
+	t.startedBy = user
+	t.preset = preset
+	t.warning = warning
+	t.warned = false
+	t.schedule()
+	return t.save(ctx)
+}
+
+// extend adds d (negative to subtract) to running or paused timer,
+// remaining time must stay positive and total duration must not exceed maxTimerDuration.
+func (t *timer) extend(d time.Duration, now time.Time) error {
+	if t.remaining(now)+d <= 0 {
+		return fmt.Errorf("can't subtract more than remaining time")
+	}
+	if t.duration+d > maxTimerDuration {
+		return fmt.Errorf("timer duration can't exceed %s", maxTimerDuration)
+	}
+	t.duration += d
+	switch t.Status {
+	case timerStatusRunning:
+		t.endsAt = t.endsAt.Add(d)
+	case timerStatusPaused:
+		t.paused += d
+	}
+	// warn again when extended beyond the warning
+	if t.remaining(now) > t.warning {
+		t.warned = false
+	}
+	return nil
+}
+
+// finish marks the timer as done and notifies clients.
+// When board has auto advance enabled, the timer continues with the next preset.
+func (t *timer) finish(ctx context.Context) error {
+	t.Status = timerStatusDone
+	t.endsAt = time.Time{}
+	t.schedule()
+	t.logger.Info("timer done")
+	if err := t.save(ctx); err != nil {
+		return err
+	}
+	if err := t.broadcast(
+		ctx,
+		broadcastMessageTopic(t.BoardID),
+		t.getStateMessage(),
+		t.getEventMessage(messageTypeTimerDone),
+	); err != nil {
+		return err
+	}
+	event := models.NewEvent(models.EventTimerDone, t.BoardID, map[string]any{"duration": t.duration.String(), "started_by": t.startedBy})
+	if err := publishEvent(ctx, t.nats.Conn, event); err != nil {
+		t.logger.Warn("failed to publish timer done event", "err", err.Error())
+	}
+
+	board, err := t.store.Boards.Get(ctx, t.BoardID)
+	if err != nil {
+		return fmt.Errorf("failed to get board: %s", err.Error())
+	}
+	// board locked or archived in the meantime keeps the timer done
+	if !board.TimerAutoAdvance || board.ReadOnly() {
+		return nil
+	}
+	preset, ok := nextPreset(board.TimerPresets, t.preset)
+	if !ok {
+		return nil
+	}
+	d, err := parseTimerDuration(preset)
+	if err != nil {
+		return err
+	}
+	if err = t.begin(ctx, d, preset, time.Duration(board.TimerWarning)*time.Second, t.startedBy); err != nil {
+		return err
+	}
+
+	t.logger.Info("timer advanced", "preset", preset)
+	statusMessage := fmt.Sprintf("Timer moved on to the next phase (%s)", preset)
+	return t.broadcast(
+		ctx,
+		broadcastMessageTopic(t.BoardID),
+		t.getStateMessage(),
+		t.getNotificationMessage(statusMessage, models.User{}),
+	)
+}
+
+func newTimer(boardID uuid.UUID, owner string, nats_ *natsutil.NATS, store *store.Store, logger *slog.Logger) *timer {
 	return &timer{
-		BoardID:  boardID,
-		Status:   timerStatusStopped,
-		Display:  "00:00",
-		nats:     nats_,
-		logger:   logger,
-		cmdChan:  make(chan *nats.Msg, 256),
-		stopChan: make(chan bool),
+		BoardID:   boardID,
+		Status:    timerStatusStopped,
+		owner:     owner,
+		nats:      nats_,
+		store:     store,
+		logger:    logger.With("board_id", boardID),
+		cmdChan:   make(chan *nats.Msg, 256),
+		stopChan:  make(chan bool),
+		done:      make(chan struct{}),
+		changedAt: time.Now(),
 	}
 }
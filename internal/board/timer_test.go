@@ -0,0 +1,197 @@
+package board
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTimer() *timer {
+	return newTimer(uuid.New(), "owner", nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func Test_timer_restore(t *testing.T) {
+	t.Run("running", func(t *testing.T) {
+		tm := testTimer()
+		endsAt := time.Now().Add(90 * time.Minute)
+		tm.restore(models.Timer{Status: "running", Duration: 2 * time.Hour, EndsAt: endsAt.UnixMilli()})
+
+		assert.Equal(t, timerStatusRunning, tm.Status)
+		assert.Equal(t, endsAt.UnixMilli(), tm.endsAt.UnixMilli())
+		assert.InDelta(t, 90*time.Minute, tm.remaining(time.Now()), float64(time.Second))
+		assert.NotNil(t, tm.alarm)
+	})
+
+	t.Run("running but already ended", func(t *testing.T) {
+		tm := testTimer()
+		tm.restore(models.Timer{Status: "running", Duration: time.Minute, EndsAt: time.Now().Add(-time.Second).UnixMilli()})
+
+		assert.Equal(t, timerStatusDone, tm.Status)
+		assert.Equal(t, time.Duration(0), tm.remaining(time.Now()))
+		assert.Nil(t, tm.alarm)
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		tm := testTimer()
+		tm.restore(models.Timer{Status: "paused", Duration: time.Minute, Remaining: 20 * time.Second})
+
+		assert.Equal(t, timerStatusPaused, tm.Status)
+		assert.Equal(t, 20*time.Second, tm.remaining(time.Now().Add(time.Hour)))
+		assert.Nil(t, tm.alarm)
+	})
+}
+
+func Test_timer_state(t *testing.T) {
+	tm := testTimer()
+	tm.Status = timerStatusRunning
+	tm.duration = 5 * time.Minute
+	tm.endsAt = time.Now().Add(3 * time.Minute)
+
+	s := tm.state()
+	assert.Equal(t, "running", s.Status)
+	assert.Equal(t, tm.endsAt.UnixMilli(), s.EndsAt)
+
+	restored := testTimer()
+	restored.restore(s)
+	assert.Equal(t, tm.Status, restored.Status)
+	assert.Equal(t, tm.duration, restored.duration)
+	assert.Equal(t, tm.endsAt.UnixMilli(), restored.endsAt.UnixMilli())
+}
+
+func Test_timer_getStateMessage(t *testing.T) {
+	tm := testTimer()
+	tm.Status = timerStatusPaused
+	tm.duration = 2 * time.Hour
+	tm.paused = 75 * time.Minute
+
+	state := tm.getStateMessage().Data.(timerState)
+	assert.Equal(t, timerStatusPaused, state.Status)
+	assert.Equal(t, (2 * time.Hour).Milliseconds(), state.Duration)
+	assert.Equal(t, (75 * time.Minute).Milliseconds(), state.Remaining)
+	assert.Zero(t, state.EndsAt)
+}
+
+func Test_parseTimerDuration(t *testing.T) {
+	d, err := parseTimerDuration("1h30m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	for _, v := range []string{"", "abc", "0s", "-1m", "25h"} {
+		_, err := parseTimerDuration(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func Test_nextPreset(t *testing.T) {
+	presets := []string{"3m", "5m", "10m"}
+
+	next, ok := nextPreset(presets, "3m")
+	assert.True(t, ok)
+	assert.Equal(t, "5m", next)
+
+	_, ok = nextPreset(presets, "10m")
+	assert.False(t, ok)
+
+	_, ok = nextPreset(presets, "7m")
+	assert.False(t, ok)
+
+	_, ok = nextPreset(presets, "")
+	assert.False(t, ok)
+}
+
+func Test_timer_extend(t *testing.T) {
+	t.Run("add to running", func(t *testing.T) {
+		now := time.Now()
+		tm := testTimer()
+		tm.Status = timerStatusRunning
+		tm.duration = 5 * time.Minute
+		tm.endsAt = now.Add(10 * time.Second)
+		tm.warning = 30 * time.Second
+		tm.warned = true
+
+		assert.NoError(t, tm.extend(time.Minute, now))
+		assert.Equal(t, 6*time.Minute, tm.duration)
+		assert.Equal(t, 70*time.Second, tm.remaining(now))
+		assert.False(t, tm.warned)
+	})
+
+	t.Run("subtract from paused", func(t *testing.T) {
+		now := time.Now()
+		tm := testTimer()
+		tm.Status = timerStatusPaused
+		tm.duration = 5 * time.Minute
+		tm.paused = 2 * time.Minute
+
+		assert.NoError(t, tm.extend(-time.Minute, now))
+		assert.Equal(t, 4*time.Minute, tm.duration)
+		assert.Equal(t, time.Minute, tm.remaining(now))
+	})
+
+	t.Run("subtract more than remaining", func(t *testing.T) {
+		now := time.Now()
+		tm := testTimer()
+		tm.Status = timerStatusRunning
+		tm.duration = 5 * time.Minute
+		tm.endsAt = now.Add(time.Minute)
+
+		assert.Error(t, tm.extend(-time.Minute, now))
+		assert.Equal(t, 5*time.Minute, tm.duration)
+	})
+
+	t.Run("exceed max duration", func(t *testing.T) {
+		now := time.Now()
+		tm := testTimer()
+		tm.Status = timerStatusPaused
+		tm.duration = maxTimerDuration
+		tm.paused = time.Minute
+
+		assert.Error(t, tm.extend(time.Minute, now))
+	})
+}
+
+func Test_timer_schedule_warning(t *testing.T) {
+	t.Run("before warning window", func(t *testing.T) {
+		tm := testTimer()
+		tm.restore(models.Timer{Status: "running", EndsAt: time.Now().Add(time.Minute).UnixMilli(), Warning: 30 * time.Second})
+		assert.False(t, tm.warned)
+		assert.NotNil(t, tm.warn)
+	})
+
+	t.Run("within warning window", func(t *testing.T) {
+		// previous owner might have warned already
+		tm := testTimer()
+		tm.restore(models.Timer{Status: "running", EndsAt: time.Now().Add(20 * time.Second).UnixMilli(), Warning: 30 * time.Second})
+		assert.True(t, tm.warned)
+		assert.Nil(t, tm.warn)
+		assert.NotNil(t, tm.alarm)
+	})
+
+	t.Run("not running", func(t *testing.T) {
+		tm := testTimer()
+		tm.restore(models.Timer{Status: "paused", Remaining: time.Minute, Warning: 30 * time.Second})
+		assert.Nil(t, tm.warn)
+		assert.Nil(t, tm.alarm)
+	})
+}
+
+func Test_timer_inactive(t *testing.T) {
+	tm := testTimer()
+	now := time.Now()
+	assert.False(t, tm.inactive(now))
+	assert.True(t, tm.inactive(now.Add(timerIdleTimeout)))
+
+	tm.Status = timerStatusDone
+	tm.changedAt = now.Add(-timerIdleTimeout - time.Second)
+	assert.True(t, tm.inactive(now))
+
+	// running and paused timers are never inactive
+	tm.Status = timerStatusPaused
+	assert.False(t, tm.inactive(now))
+	tm.Status = timerStatusRunning
+	assert.False(t, tm.inactive(now))
+}
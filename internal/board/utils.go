@@ -12,10 +12,26 @@ func timerCmdTopic(boardID uuid.UUID) string {
 	return fmt.Sprintf("boards.%s.timer.cmd", boardID)
 }
 
+// timerHandoffTopics matches timer handoff topic of all boards
+const timerHandoffTopics = "boards.*.timer.handoff"
+
+func timerHandoffTopic(boardID uuid.UUID) string {
+	return fmt.Sprintf("boards.%s.timer.handoff", boardID)
+}
+
+func timerLeaseKey(boardID uuid.UUID) string {
+	return fmt.Sprintf("timers.%s", boardID)
+}
+
 func broadcastMessageTopic(boardID uuid.UUID) string {
 	return fmt.Sprintf("boards.%s.msg.out", boardID)
 }
 
+// userUpdatedTopic is where user profile changes published, to all clients of the user
+func userUpdatedTopic(userID uuid.UUID) string {
+	return fmt.Sprintf("users.%s.updated", userID)
+}
+
 func queryTimerStatus(conn *nats.Conn, boardID uuid.UUID) (*nats.Msg, error) {
 	cmdMsg := message{
 		Type: messageTypeTimerCmd,
@@ -11,5 +11,7 @@ import (
 func TestTopics(t *testing.T) {
 	id := uuid.New()
 	assert.Equal(t, fmt.Sprintf("boards.%s.timer.cmd", id), timerCmdTopic(id))
+	assert.Equal(t, fmt.Sprintf("boards.%s.timer.handoff", id), timerHandoffTopic(id))
 	assert.Equal(t, fmt.Sprintf("boards.%s.msg.out", id), broadcastMessageTopic(id))
+	assert.Equal(t, fmt.Sprintf("users.%s.updated", id), userUpdatedTopic(id))
 }
@@ -0,0 +1,138 @@
+// Package chatops implements slash commands of Slack and Mattermost compatible chat apps.
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/models"
+)
+
+// maxRequestAge is how old signed Slack request can be, older requests might be replayed
+const maxRequestAge = 5 * time.Minute
+
+// Maximum number of action items listed in summary.
+const maxActionItems = 10
+
+// ErrUnverified returned when request signature or token doesn't match
+var ErrUnverified = errors.New("slash command request not verified")
+
+// Response types, ephemeral response only shown to the user who sent the command
+const (
+	InChannel = "in_channel"
+	Ephemeral = "ephemeral"
+)
+
+// Response is slash command response, understood by both Slack and Mattermost
+type Response struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// VerifySlack verifies Slack request signature, made with the app signing secret
+func VerifySlack(secret string, header http.Header, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return ErrUnverified
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("%w: request too old", ErrUnverified)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:", ts)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrUnverified
+	}
+	return nil
+}
+
+// VerifyMattermost verifies Mattermost slash command token, sent in Authorization header and in the form
+func VerifyMattermost(token string, header http.Header, form url.Values) error {
+	got, ok := strings.CutPrefix(header.Get("Authorization"), "Token ")
+	if !ok {
+		got = form.Get("token")
+	}
+	if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return ErrUnverified
+	}
+	return nil
+}
+
+// Help returns usage of the command
+func Help(command string) Response {
+	if command == "" {
+		command = "/retro"
+	}
+	return Response{
+		ResponseType: Ephemeral,
+		Text: fmt.Sprintf("Usage:\n`%[1]s new [template]` creates a board, templates: %s\n`%[1]s summary <board link or ID>` shows top voted cards and action items of a board",
+			command, strings.Join(board.TemplateNames(), ", ")),
+	}
+}
+
+// NewBoard returns response with link to new board
+func NewBoard(link, template string) Response {
+	text := "New retro board: " + link
+	if template != "" {
+		text = fmt.Sprintf("New %s retro board: %s", template, link)
+	}
+	return Response{ResponseType: InChannel, Text: text}
+}
+
+// Summary returns response with top voted cards and action items of the board
+func Summary(link string, s *board.Summary) Response {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Retro summary of %s\n", link)
+
+	b.WriteString("\nTop voted:\n")
+	if len(s.TopCards) == 0 {
+		b.WriteString("no votes yet\n")
+	}
+	for _, c := range s.TopCards {
+		fmt.Fprintf(&b, "• %s (%s, %s)\n", escape(c.Name), escape(s.ColumnName(c.ColumnID)), votes(c))
+	}
+
+	b.WriteString("\nAction items:\n")
+	if len(s.ActionItems) == 0 {
+		b.WriteString("none\n")
+	}
+	for i, c := range s.ActionItems {
+		if i == maxActionItems {
+			fmt.Fprintf(&b, "and %d more\n", len(s.ActionItems)-maxActionItems)
+			break
+		}
+		fmt.Fprintf(&b, "• %s\n", escape(c.Name))
+	}
+	return Response{ResponseType: InChannel, Text: strings.TrimSuffix(b.String(), "\n")}
+}
+
+// textEscaper escapes control characters of Slack message text, so names can't mention channels or users, or add links
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escape(text string) string {
+	return textEscaper.Replace(text)
+}
+
+func votes(c models.Card) string {
+	if c.Votes == 1 {
+		return "1 vote"
+	}
+	return fmt.Sprintf("%d votes", c.Votes)
+}
+
+// Error returns response with error message, only shown to the user
+func Error(format string, args ...any) Response {
+	return Response{ResponseType: Ephemeral, Text: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,79 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySlack(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte("command=%2Fretro&text=new")
+	sign := func(secret string, ts int64) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "v0:%d:%s", ts, body)
+		h := http.Header{}
+		h.Set("X-Slack-Request-Timestamp", fmt.Sprint(ts))
+		h.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return h
+	}
+
+	assert.NoError(t, VerifySlack("secret", sign("secret", now.Unix()), body, now))
+	assert.ErrorIs(t, VerifySlack("secret", sign("other", now.Unix()), body, now), ErrUnverified)
+	assert.ErrorIs(t, VerifySlack("secret", sign("secret", now.Add(-6*time.Minute).Unix()), body, now), ErrUnverified)
+	assert.ErrorIs(t, VerifySlack("secret", sign("secret", now.Unix()), append(body, 'x'), now), ErrUnverified)
+	assert.ErrorIs(t, VerifySlack("secret", http.Header{}, body, now), ErrUnverified)
+}
+
+func TestVerifyMattermost(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Token abc")
+	assert.NoError(t, VerifyMattermost("abc", header, url.Values{}))
+	assert.NoError(t, VerifyMattermost("abc", http.Header{}, url.Values{"token": {"abc"}}))
+	assert.ErrorIs(t, VerifyMattermost("abc", http.Header{}, url.Values{"token": {"abd"}}), ErrUnverified)
+	assert.ErrorIs(t, VerifyMattermost("abc", http.Header{}, url.Values{}), ErrUnverified)
+}
+
+func TestSummary(t *testing.T) {
+	b := models.NewBoard(uuid.New())
+	good := models.NewColumn("Good", b.ID)
+	card := models.NewCard("pairing", b.ID, good.ID)
+	card.Votes = 2
+	s := &board.Summary{
+		Board:       &b,
+		Columns:     []board.ColumnStats{{Column: good}},
+		TopCards:    []models.Card{card},
+		ActionItems: []models.Card{models.NewCard("fix ci", b.ID, uuid.New())},
+	}
+	resp := Summary("https://retro.example.com/b/1", s)
+	assert.Equal(t, InChannel, resp.ResponseType)
+	assert.Equal(t, "Retro summary of https://retro.example.com/b/1\n\nTop voted:\n• pairing (Good, 2 votes)\n\nAction items:\n• fix ci", resp.Text)
+
+	resp = Summary("link", &board.Summary{Board: &b})
+	assert.Contains(t, resp.Text, "no votes yet")
+	assert.Contains(t, resp.Text, "none")
+
+	// names can't mention channel or add links
+	col := models.NewColumn("Q&A <#C123>", b.ID)
+	card = models.NewCard("<!channel> see <https://evil.example.com|this>", b.ID, col.ID)
+	resp = Summary("link", &board.Summary{
+		Board:       &b,
+		Columns:     []board.ColumnStats{{Column: col}},
+		TopCards:    []models.Card{card},
+		ActionItems: []models.Card{card},
+	})
+	assert.NotContains(t, resp.Text, "<!channel>")
+	assert.NotContains(t, resp.Text, "<https://")
+	assert.Contains(t, resp.Text, "• &lt;!channel&gt; see &lt;https://evil.example.com|this&gt; (Q&amp;A &lt;#C123&gt;, 0 votes)\n")
+	assert.Contains(t, resp.Text, "Action items:\n• &lt;!channel&gt; see")
+}
@@ -0,0 +1,85 @@
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultGitHubURL is the base URL of GitHub REST API, GitHub Enterprise Server has its own (e.g https://github.example.com/api/v3).
+const DefaultGitHubURL = "https://api.github.com"
+
+// Timeout of requests to GitHub API.
+const gitHubTimeout = 10 * time.Second
+
+// GitHub creates issues in a GitHub repository
+type GitHub struct {
+	baseURL string
+	repo    string // owner/name
+	token   string
+	client  *http.Client
+}
+
+// NewGitHub returns GitHub tracker of the repository (owner/name), the token must be allowed to create its issues
+func NewGitHub(baseURL, repo, token string) (*GitHub, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid GitHub repository %q, must be owner/name", repo)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("GitHub token is required")
+	}
+	return &GitHub{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		repo:    repo,
+		token:   token,
+		client:  &http.Client{Timeout: gitHubTimeout},
+	}, nil
+}
+
+// Name returns name of the tracker shown to users
+func (g *GitHub) Name() string {
+	return "GitHub"
+}
+
+// Create creates the issue in the repository and returns its URL
+func (g *GitHub) Create(ctx context.Context, issue Issue) (string, error) {
+	body, err := json.Marshal(map[string]string{"title": issue.Title, "body": issue.Body})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/repos/"+g.repo+"/issues", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		HTMLURL string `json:"html_url"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil && resp.StatusCode == http.StatusCreated {
+		return "", fmt.Errorf("invalid GitHub response: %s", err.Error())
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("GitHub responded %d: %s", resp.StatusCode, result.Message)
+	}
+	if result.HTMLURL == "" {
+		return "", fmt.Errorf("invalid GitHub response: html_url missing")
+	}
+	return result.HTMLURL, nil
+}
@@ -0,0 +1,48 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitHub(t *testing.T) {
+	for _, repo := range []string{"", "owner", "owner/", "/name", "owner/name/x"} {
+		_, err := NewGitHub(DefaultGitHubURL, repo, "token")
+		assert.Error(t, err, repo)
+	}
+	_, err := NewGitHub(DefaultGitHubURL, "owner/name", "")
+	assert.Error(t, err)
+}
+
+func TestGitHubCreate(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/acme/retro/issues" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number": 7, "html_url": "https://github.com/acme/retro/issues/7"}`))
+	}))
+	defer srv.Close()
+
+	gh, err := NewGitHub(srv.URL+"/", "acme/retro", "secret")
+	require.NoError(t, err)
+	url, err := gh.Create(context.Background(), Issue{Title: "fix ci", Body: "from retro"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/acme/retro/issues/7", url)
+	assert.Equal(t, map[string]string{"title": "fix ci", "body": "from retro"}, got)
+
+	gh, err = NewGitHub(srv.URL, "acme/other", "secret")
+	require.NoError(t, err)
+	_, err = gh.Create(context.Background(), Issue{Title: "fix ci"})
+	assert.EqualError(t, err, "GitHub responded 404: Not Found")
+}
@@ -0,0 +1,19 @@
+// Package issues creates issues of board cards (e.g action items) in issue trackers.
+package issues
+
+import "context"
+
+// Issue is the issue created for a card
+type Issue struct {
+	Title string
+	Body  string
+}
+
+// Tracker creates issues in an issue tracker, e.g GitHub Issues
+type Tracker interface {
+	// Name returns name of the tracker shown to users
+	Name() string
+
+	// Create creates the issue and returns its URL
+	Create(ctx context.Context, issue Issue) (string, error)
+}
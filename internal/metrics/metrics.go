@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goretro"
+
+var (
+	// ActiveClients is the number of websocket clients connected to this instance.
+	ActiveClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_clients",
+		Help:      "Number of websocket clients connected to this instance.",
+	})
+
+	// ActiveBoards is the number of boards with at least one client connected to this instance.
+	ActiveBoards = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_boards",
+		Help:      "Number of boards with clients connected to this instance.",
+	})
+
+	// ActiveTimers is the number of timer processes running on this instance.
+	ActiveTimers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_timers",
+		Help:      "Number of board timers running on this instance.",
+	})
+
+	// TimerExits counts timer processes exited on this instance, by reason.
+	TimerExits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timer_exits_total",
+		Help:      "Number of board timer processes exited on this instance.",
+	}, []string{"reason"})
+
+	// TimerLimitRejections counts timers not started because the instance reached its maximum.
+	TimerLimitRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timer_limit_rejections_total",
+		Help:      "Number of board timers not started because instance limit reached.",
+	})
+
+	// Messages counts websocket messages received from clients, by message type.
+	Messages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_total",
+		Help:      "Number of websocket messages received from clients.",
+	}, []string{"type"})
+
+	// HandlerErrors counts errors returned while handling messages, by message type.
+	HandlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handler_errors_total",
+		Help:      "Number of errors while handling websocket messages.",
+	}, []string{"type"})
+
+	// StoreOperationDuration observes latency of store operations, by repo and method.
+	StoreOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "store_operation_duration_seconds",
+		Help:      "Latency of store operations.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repo", "method"})
+
+	// PublishFailures counts failed NATS publishes, by source.
+	PublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_publish_failures_total",
+		Help:      "Number of failed NATS publishes.",
+	}, []string{"source"})
+
+	// NATSDisconnects counts lost NATS connections.
+	NATSDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nats_disconnects_total",
+		Help:      "Number of times NATS connection lost.",
+	})
+
+	// ThrottledMessages counts messages dropped by rate limiter, by scope (client or board) and message type.
+	ThrottledMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "throttled_messages_total",
+		Help:      "Number of websocket messages dropped by rate limiter.",
+	}, []string{"scope", "type"})
+
+	// ThrottledDisconnects counts clients disconnected for repeatedly exceeding rate limits.
+	ThrottledDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "throttled_disconnects_total",
+		Help:      "Number of websocket clients disconnected for exceeding rate limits.",
+	})
+
+	// SpectatorRejections counts messages rejected because they're sent by spectators.
+	SpectatorRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spectator_rejections_total",
+		Help:      "Number of websocket messages rejected because spectators can't change the board.",
+	})
+
+	// BoardLimitRejections counts creations rejected because board reached its maximum, by resource.
+	BoardLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "board_limit_rejections_total",
+		Help:      "Number of columns or cards rejected because board limit reached.",
+	}, []string{"resource"})
+
+	// WebhookDeliveries counts attempts to deliver events to webhooks, by result (ok, retry or failed).
+	WebhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of attempts to deliver events to webhooks.",
+	}, []string{"result"})
+)
+
+// Handler returns http handler that exposes all registered metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"slices"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 )
@@ -13,8 +16,18 @@ type User struct {
 	ID       uuid.UUID `json:"id"`
 	Name     string    `json:"name"`
 	AvatarID int       `json:"avatar_id"`
+
+	// AvatarURL of uploaded avatar, or of the provider for authenticated users.
+	// Authenticated users logged in via OIDC, their name and avatar come from the provider.
+	AvatarURL     string `json:"avatar_url,omitempty"`
+	Authenticated bool   `json:"authenticated,omitempty"`
 }
 
+// AvatarsCount is the total number of bundled avatars available to choose from, their IDs start from 1.
+// Avatar ID 0 is the avatar generated from user ID.
+// see: web/ui/public/avatar
+const AvatarsCount = 12
+
 func NewUser(avatarID int) User {
 	return User{
 		ID:       uuid.New(),
@@ -22,15 +35,45 @@ func NewUser(avatarID int) User {
 	}
 }
 
+// DefaultTimerPresets are timer durations offered on new boards
+var DefaultTimerPresets = []string{"3m", "5m", "10m"}
+
 type Board struct {
 	ID        uuid.UUID `json:"id"`
 	CreatedAt int64     `json:"created_at"`
+
+	// TimerPresets are durations (e.g "5m") offered when starting the timer,
+	// with TimerAutoAdvance enabled they're also the phases the timer goes through.
+	TimerPresets     []string `json:"timer_presets"`
+	TimerWarning     int      `json:"timer_warning"` // seconds before timer done to warn clients, 0 disables
+	TimerAutoAdvance bool     `json:"timer_auto_advance"`
+
+	RequireAuth bool `json:"require_auth"` // only authenticated users can join
+
+	// FacilitatorID is the user who created the board, only they can manage its access.
+	// Private board can only be joined with its passcode or invite link, access granted before
+	// AccessEpoch changed (e.g invites revoked) is no longer valid.
+	FacilitatorID uuid.UUID `json:"facilitator_id"`
+	Private       bool      `json:"private"`
+	AccessEpoch   int       `json:"access_epoch"`
+
+	// Locked board can't be changed by anyone until facilitator unlocks it,
+	// Archived board is also read-only and it's hidden from board lists.
+	Locked   bool `json:"locked"`
+	Archived bool `json:"archived"`
+}
+
+// ReadOnly reports whether the board can't be changed, i.e it's locked or archived
+func (b Board) ReadOnly() bool {
+	return b.Locked || b.Archived
 }
 
 func NewBoard(id uuid.UUID) Board {
 	return Board{
-		ID:        id,
-		CreatedAt: time.Now().Unix(),
+		ID:           id,
+		CreatedAt:    time.Now().Unix(),
+		TimerPresets: DefaultTimerPresets,
+		TimerWarning: 30,
 	}
 }
 
@@ -41,6 +84,13 @@ type Column struct {
 	CreatedAt int64     `json:"created_at"`
 }
 
+// IsActionItems returns whether cards of the column are action items, i.e the column name has word "action" or "actions"
+// e.g "Action items" or "Next actions", but not "Reactions".
+func (c Column) IsActionItems() bool {
+	words := strings.FieldsFunc(strings.ToLower(c.Name), func(r rune) bool { return !unicode.IsLetter(r) })
+	return slices.ContainsFunc(words, func(w string) bool { return w == "action" || w == "actions" })
+}
+
 func NewColumn(name string, boardID uuid.UUID) Column {
 	return Column{
 		ID:        uuid.New(),
@@ -57,6 +107,10 @@ type Card struct {
 	ColumnID  uuid.UUID `json:"column_id"`
 	Votes     int       `json:"votes"`
 	CreatedAt int64     `json:"created_at"`
+	IssueURL  string    `json:"issue_url,omitempty"` // issue created from the card in issue tracker
+
+	// IssueClaimedAt is when creating issue of the card started, so only one issue is created for it
+	IssueClaimedAt int64 `json:"issue_claimed_at,omitempty"`
 }
 
 func NewCard(name string, boardID, columnID uuid.UUID) Card {
@@ -75,6 +129,7 @@ type Client struct {
 	BoardID   uuid.UUID `json:"board_id"`
 	User      *User     `json:"user"`
 	CreatedAt int64     `json:"created_at"`
+	Spectator bool      `json:"spectator,omitempty"` // only watches the board, can't change it
 }
 
 func NewClient(user *User, boardID uuid.UUID) Client {
@@ -85,3 +140,82 @@ func NewClient(user *User, boardID uuid.UUID) Client {
 		CreatedAt: time.Now().Unix(),
 	}
 }
+
+// Timer holds the persisted state of a board timer,
+// allowing any instance to resume the timer when its owner is gone.
+type Timer struct {
+	BoardID   uuid.UUID     `json:"board_id"`
+	Status    string        `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	EndsAt    int64         `json:"ends_at"`   // unix milliseconds, only set when running
+	Remaining time.Duration `json:"remaining"` // only set when paused
+	StartedBy *User         `json:"started_by"`
+	Preset    string        `json:"preset"`  // preset the timer started from, if any
+	Warning   time.Duration `json:"warning"` // how long before done clients are warned
+	UpdatedAt int64         `json:"updated_at"`
+}
+
+// APIToken lets scripts and integrations use the REST API on behalf of the user.
+// Only hash of the token secret is stored, the token itself is only shown when created.
+type APIToken struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"-"`
+	CreatedAt int64     `json:"created_at"`
+	ExpiresAt int64     `json:"expires_at"`
+}
+
+// Types of board events sent to webhooks
+const (
+	EventBoardCreated      = "board.created"
+	EventCardCreated       = "card.created"
+	EventActionItemCreated = "action_item.created"
+	EventTimerDone         = "timer.done"
+)
+
+// EventTypes are all types of board events
+var EventTypes = []string{EventBoardCreated, EventCardCreated, EventActionItemCreated, EventTimerDone}
+
+// Event is something that happened on a board, e.g card created
+type Event struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	BoardID   uuid.UUID `json:"board_id"`
+	CreatedAt int64     `json:"created_at"`
+	Data      any       `json:"data,omitempty"`
+}
+
+func NewEvent(typ string, boardID uuid.UUID, data any) Event {
+	return Event{
+		ID:        uuid.New(),
+		Type:      typ,
+		BoardID:   boardID,
+		CreatedAt: time.Now().Unix(),
+		Data:      data,
+	}
+}
+
+// Webhook receives board events of given types (all when empty) as signed JSON requests.
+// Global webhooks (configured for all boards) have no board ID.
+type Webhook struct {
+	ID        uuid.UUID `json:"id"`
+	BoardID   uuid.UUID `json:"board_id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedAt int64     `json:"created_at"`
+}
+
+// WebhookDelivery is a log of an attempt to deliver event to webhook
+type WebhookDelivery struct {
+	ID         uuid.UUID `json:"id"`
+	WebhookID  uuid.UUID `json:"webhook_id"`
+	EventID    uuid.UUID `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Duration   int64     `json:"duration_ms"`
+	CreatedAt  int64     `json:"created_at"`
+}
@@ -0,0 +1,35 @@
+// Package natstest provides embedded NATS server for tests.
+package natstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// Server starts embedded NATS server with JetStream enabled on random port,
+// it's shut down when the test ends. Tests using it are skipped in short mode.
+func Server(t testing.TB) *server.Server {
+	t.Helper()
+	return Run(t, server.RANDOM_PORT, t.TempDir())
+}
+
+// Run starts embedded NATS server with JetStream enabled on given port, storing JetStream data in storeDir,
+// e.g to restart the server with the same data.
+func Run(t testing.TB, port int, storeDir string) *server.Server {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, JetStream: true, StoreDir: storeDir, NoSigs: true})
+	if err != nil {
+		t.Fatalf("error creating NATS server: %s", err.Error())
+	}
+	srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv
+}
@@ -2,47 +2,135 @@ package natsutil
 
 import (
 	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/nats.go/jetstream"
 )
 
+const (
+	// First delay between reconnect attempts, doubled on each attempt.
+	minReconnectDelay = 100 * time.Millisecond
+
+	// Maximum delay between reconnect attempts.
+	maxReconnectDelay = 5 * time.Second
+)
+
 // NATS holds reference to Conn, Jetstream and KeyValue
 type NATS struct {
 	Conn *nats.Conn
 	JS   jetstream.JetStream
+
+	mu          sync.Mutex
+	reconnected chan struct{} // closed on reconnect, then replaced
 }
 
 func (n *NATS) Close() {
 	n.Conn.Drain()
 }
 
-// Connect setups NATS connection and KV store, it panic when any error occurred
-func Connect(url, credentials string) *NATS {
-	options := []nats.Option{
-		nats.Name("goretro-web"),
+// Connected returns whether the connection is currently established
+func (n *NATS) Connected() bool {
+	return n.Conn.IsConnected()
+}
+
+// Reconnected returns channel that's closed on next reconnect.
+// Core subscriptions are restored by the client library, while anything that keeps
+// server-side state (e.g KV watchers) should be re-established once it's closed.
+func (n *NATS) Reconnected() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.reconnected == nil {
+		n.reconnected = make(chan struct{})
 	}
+	return n.reconnected
+}
 
-	if credentials != "none" {
-		// connection credentials string (base64 encoded)
-		credBytes, err := base64.StdEncoding.DecodeString(credentials)
-		if err != nil {
-			panic(err)
-		}
-		options = append(options, nats.UserCredentialBytes(credBytes))
+// notifyReconnected wakes up everyone waiting on Reconnected
+func (n *NATS) notifyReconnected() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.reconnected != nil {
+		close(n.reconnected)
+	}
+	n.reconnected = make(chan struct{})
+}
+
+// reconnectDelay returns exponential backoff delay for given reconnect attempt, with jitter
+func reconnectDelay(attempts int) time.Duration {
+	d := maxReconnectDelay
+	if attempts < 16 {
+		d = min(minReconnectDelay<<attempts, maxReconnectDelay)
+	}
+	// up to 20% jitter so instances don't reconnect all at once
+	return d - time.Duration(float64(d)*0.2*rand.Float64())
+}
+
+// options returns connection options to keep reconnecting forever and report connection state changes
+func (n *NATS) options(logger *slog.Logger) []nats.Option {
+	return []nats.Option{
+		nats.Name("goretro-web"),
+		nats.MaxReconnects(-1),
+		nats.CustomReconnectDelay(reconnectDelay),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			metrics.NATSDisconnects.Inc()
+			if err != nil {
+				logger.Warn("NATS disconnected", "err", err.Error())
+			} else {
+				logger.Warn("NATS disconnected")
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Info("NATS reconnected", "url", nc.ConnectedUrlRedacted())
+			n.notifyReconnected()
+		}),
+		nats.ClosedHandler(func(_ *nats.Conn) {
+			logger.Info("NATS connection closed")
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			if sub != nil {
+				logger.Error("NATS async error", "subject", sub.Subject, "err", err.Error())
+			} else {
+				logger.Error("NATS async error", "err", err.Error())
+			}
+		}),
 	}
+}
 
-	nc, err := nats.Connect(url, options...)
+// connect connects to NATS with given extra options
+func connect(url string, logger *slog.Logger, opts ...nats.Option) (*NATS, error) {
+	n := &NATS{}
+	nc, err := nats.Connect(url, append(n.options(logger), opts...)...)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	js, err := jetstream.New(nc)
 	if err != nil {
-		panic(err)
+		nc.Close()
+		return nil, err
 	}
+	n.Conn = nc
+	n.JS = js
+	return n, nil
+}
 
-	return &NATS{
-		Conn: nc,
-		JS:   js,
+// Connect setups NATS connection that keeps reconnecting when connection lost.
+// Initial connection is not retried, error returned instead.
+func Connect(url, credentials string, logger *slog.Logger) (*NATS, error) {
+	var options []nats.Option
+
+	if credentials != "none" {
+		// connection credentials string (base64 encoded)
+		credBytes, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS credentials: %s", err.Error())
+		}
+		options = append(options, nats.UserCredentialBytes(credBytes))
 	}
+	return connect(url, logger, options...)
 }
@@ -0,0 +1,66 @@
+package natsutil
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	assert.LessOrEqual(t, reconnectDelay(0), minReconnectDelay)
+	assert.Greater(t, reconnectDelay(0), minReconnectDelay*8/10)
+	assert.Greater(t, reconnectDelay(3), reconnectDelay(1))
+
+	// capped, even when shifting would overflow
+	for _, attempts := range []int{10, 16, 100} {
+		assert.LessOrEqual(t, reconnectDelay(attempts), maxReconnectDelay)
+		assert.Greater(t, reconnectDelay(attempts), maxReconnectDelay*8/10)
+	}
+}
+
+func TestConnect_reconnect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	opts := &server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoSigs: true}
+	srv, err := server.NewServer(opts)
+	require.NoError(t, err)
+	srv.Start()
+	require.True(t, srv.ReadyForConnections(serverReadyTimeout))
+
+	n, err := Connect(srv.ClientURL(), "none", testLogger)
+	require.NoError(t, err)
+	t.Cleanup(n.Conn.Close)
+	assert.True(t, n.Connected())
+	reconnected := n.Reconnected()
+
+	// restart the server on the same port
+	port := srv.Addr().(*net.TCPAddr).Port
+	srv.Shutdown()
+	srv.WaitForShutdown()
+	require.Eventually(t, func() bool { return !n.Connected() }, serverReadyTimeout, 10*time.Millisecond)
+
+	srv, err = server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoSigs: true})
+	require.NoError(t, err)
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(serverReadyTimeout))
+
+	select {
+	case <-reconnected:
+	case <-time.After(serverReadyTimeout):
+		t.Fatal("timeout waiting for reconnect")
+	}
+	assert.True(t, n.Connected())
+
+	// waits for the next reconnect
+	select {
+	case <-n.Reconnected():
+		t.Fatal("unexpected reconnect")
+	default:
+	}
+}
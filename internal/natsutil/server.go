@@ -0,0 +1,91 @@
+package natsutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// Time to wait for embedded server to be ready for connections.
+const serverReadyTimeout = 10 * time.Second
+
+// Interval to check whether JetStream is ready.
+const jetStreamCheckInterval = 100 * time.Millisecond
+
+// ServerConfig holds configuration of embedded NATS server
+type ServerConfig struct {
+	Name          string   // unique server name, required for clustering
+	DataDir       string   // JetStream storage directory
+	ClusterName   string   // cluster name, all servers in the cluster must use the same name
+	ClusterListen string   // host:port to listen for routes from other servers, clustering disabled when empty
+	Routes        []string // URLs of other servers in the cluster e.g nats://10.0.0.2:6222
+}
+
+// RunServer starts embedded NATS server with JetStream enabled.
+// The app connects to it in-process, so it doesn't listen for clients unless clustering is enabled
+// (routing waits for client listener), in which case it listens on random loopback port.
+func RunServer(conf ServerConfig) (*server.Server, error) {
+	opts := &server.Options{
+		ServerName: conf.Name,
+		DontListen: true,
+		JetStream:  true,
+		StoreDir:   conf.DataDir,
+		NoSigs:     true,
+	}
+
+	if conf.ClusterListen != "" {
+		host, portStr, err := net.SplitHostPort(conf.ClusterListen)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster listen address: %s", err.Error())
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster listen port: %s", err.Error())
+		}
+		opts.Cluster = server.ClusterOpts{Name: conf.ClusterName, Host: host, Port: port}
+		opts.Routes = server.RoutesFromStr(strings.Join(conf.Routes, ","))
+		opts.DontListen = false
+		opts.Host = "127.0.0.1"
+		opts.Port = server.RANDOM_PORT
+	}
+
+	srv, err := server.NewServer(opts)
+	if err != nil {
+		return nil, err
+	}
+	srv.ConfigureLogger()
+	srv.Start()
+	if !srv.ReadyForConnections(serverReadyTimeout) {
+		srv.Shutdown()
+		return nil, fmt.Errorf("embedded NATS server not ready after %s", serverReadyTimeout)
+	}
+	return srv, nil
+}
+
+// WaitJetStream waits until JetStream of embedded server is ready to serve requests.
+// In cluster mode, requests sent before metadata leader elected are never answered,
+// and leader can only be elected once quorum of the servers are up.
+func WaitJetStream(ctx context.Context, srv *server.Server) error {
+	ticker := time.NewTicker(jetStreamCheckInterval)
+	defer ticker.Stop()
+	for !srv.JetStreamIsCurrent() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("JetStream not ready: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// ConnectEmbedded setups in-process connection to embedded NATS server
+func ConnectEmbedded(srv *server.Server, logger *slog.Logger) (*NATS, error) {
+	return connect("", logger, nats.InProcessServer(srv))
+}
@@ -0,0 +1,91 @@
+package natsutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRunServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	dataDir := t.TempDir()
+	ctx := context.Background()
+
+	// write a value, restart the server then read it back
+	for _, write := range []bool{true, false} {
+		srv, err := RunServer(ServerConfig{Name: "test", DataDir: dataDir})
+		require.NoError(t, err)
+
+		nc, err := ConnectEmbedded(srv, testLogger)
+		require.NoError(t, err)
+
+		kv, err := nc.JS.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: "test"})
+		require.NoError(t, err)
+		if write {
+			_, err = kv.Put(ctx, "key", []byte("value"))
+			require.NoError(t, err)
+		} else {
+			entry, err := kv.Get(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, "value", string(entry.Value()))
+		}
+
+		nc.Conn.Close()
+		srv.Shutdown()
+		srv.WaitForShutdown()
+	}
+}
+
+func TestRunServer_invalidCluster(t *testing.T) {
+	_, err := RunServer(ServerConfig{Name: "test", DataDir: t.TempDir(), ClusterListen: "6222"})
+	assert.Error(t, err)
+}
+
+func TestRunServer_cluster(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	a, err := RunServer(ServerConfig{Name: "a", DataDir: t.TempDir(), ClusterName: "test", ClusterListen: "127.0.0.1:-1"})
+	require.NoError(t, err)
+	t.Cleanup(a.Shutdown)
+
+	route := fmt.Sprintf("nats://%s", a.ClusterAddr())
+	b, err := RunServer(ServerConfig{Name: "b", DataDir: t.TempDir(), ClusterName: "test", ClusterListen: "127.0.0.1:-1", Routes: []string{route}})
+	require.NoError(t, err)
+	t.Cleanup(b.Shutdown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, WaitJetStream(ctx, a))
+	require.NoError(t, WaitJetStream(ctx, b))
+
+	// value written through one server can be read through the other
+	ncA, err := ConnectEmbedded(a, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(ncA.Conn.Close)
+	ncB, err := ConnectEmbedded(b, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(ncB.Conn.Close)
+
+	kvA, err := ncA.JS.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: "test"})
+	require.NoError(t, err)
+	_, err = kvA.Put(ctx, "key", []byte("value"))
+	require.NoError(t, err)
+
+	kvB, err := ncB.JS.KeyValue(ctx, "test")
+	require.NoError(t, err)
+	entry, err := kvB.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(entry.Value()))
+}
@@ -0,0 +1,62 @@
+package natstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// header of blob content type
+const contentTypeHeader = "Content-Type"
+
+type blobs struct {
+	os jetstream.ObjectStore
+}
+
+func (b *blobs) Put(ctx context.Context, key string, blob store.Blob) error {
+	ctx, done := track(ctx, "blobs", "Put")
+	defer done()
+	_, err := b.os.Put(ctx, jetstream.ObjectMeta{
+		Name:    key,
+		Headers: nats.Header{contentTypeHeader: []string{blob.ContentType}},
+	}, bytes.NewReader(blob.Data))
+	return err
+}
+
+func (b *blobs) Get(ctx context.Context, key string) (*store.Blob, error) {
+	ctx, done := track(ctx, "blobs", "Get")
+	defer done()
+	res, err := b.os.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrObjectNotFound) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	info, err := res.Info()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(res)
+	if err != nil {
+		return nil, err
+	}
+	return &store.Blob{Data: data, ContentType: info.Headers.Get(contentTypeHeader), ModTime: info.ModTime}, nil
+}
+
+func (b *blobs) Delete(ctx context.Context, key string) error {
+	ctx, done := track(ctx, "blobs", "Delete")
+	defer done()
+	err := b.os.Delete(ctx, key)
+	if errors.Is(err, jetstream.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go/jetstream"
 )
@@ -19,7 +21,31 @@ func (u *boards) key(id uuid.UUID) string {
 	return fmt.Sprintf("boards.%s", id)
 }
 
+// facilitatorKey is the key indexing the board by its facilitator, it's written along with the board so they expire together
+func (b *boards) facilitatorKey(facilitatorID, id uuid.UUID) string {
+	return fmt.Sprintf("facilitators.%s.boards.%s", facilitatorID, id)
+}
+
+// tombstoneKey is the key marking the board deleted, it's kept until it expires like other records
+func (b *boards) tombstoneKey(id uuid.UUID) string {
+	return fmt.Sprintf("tombstones.boards.%s", id)
+}
+
+// deleted returns store.ErrDeleted when the board was deleted
+func (b *boards) deleted(ctx context.Context, id uuid.UUID) error {
+	_, err := b.kv.Get(ctx, b.tombstoneKey(id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return store.ErrDeleted
+}
+
 func (b *boards) List(ctx context.Context, limit int) ([]models.Board, error) {
+	ctx, done := track(ctx, "boards", "List")
+	defer done()
 	var boards []models.Board
 	lister, err := b.kv.ListKeysFiltered(ctx, "boards.*")
 	if err != nil {
@@ -45,22 +71,67 @@ func (b *boards) List(ctx context.Context, limit int) ([]models.Board, error) {
 	return boards, nil
 }
 
+func (b *boards) ListByFacilitator(ctx context.Context, facilitatorID uuid.UUID, limit int) ([]models.Board, error) {
+	ctx, done := track(ctx, "boards", "ListByFacilitator")
+	defer done()
+	keys, err := b.listKeys(ctx, fmt.Sprintf("facilitators.%s.boards.*", facilitatorID))
+	if err != nil {
+		return nil, err
+	}
+
+	var list []models.Board
+	for _, key := range keys {
+		id, err := uuid.Parse(key[strings.LastIndex(key, ".")+1:])
+		if err != nil {
+			continue
+		}
+		board, err := b.Get(ctx, id)
+		if errors.Is(err, store.ErrNotFound) {
+			continue // expired or deleted in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *board)
+		if len(list) >= limit {
+			break
+		}
+	}
+	return list, nil
+}
+
 func (b *boards) Create(ctx context.Context, board models.Board) error {
-	key := b.key(board.ID)
-	_, err := b.kv.Get(ctx, key)
-	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
+	ctx, done := track(ctx, "boards", "Create")
+	defer done()
+	if err := b.deleted(ctx, board.ID); err != nil {
 		return err
 	}
 	val, err := json.Marshal(board)
 	if err != nil {
 		return err
 	}
-	_, err = b.kv.Put(ctx, key, val)
+	// only one of concurrent creates wins, so the board and its facilitator index are never overwritten
+	_, err = b.kv.Create(ctx, b.key(board.ID), val)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return store.ErrExists
+	}
+	if err != nil {
+		return err
+	}
+	_, err = b.kv.Put(ctx, b.facilitatorKey(board.FacilitatorID, board.ID), nil)
 	return err
 }
 
 func (b *boards) Get(ctx context.Context, id uuid.UUID) (*models.Board, error) {
+	ctx, done := track(ctx, "boards", "Get")
+	defer done()
 	val, err := b.kv.Get(ctx, b.key(id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		if err := b.deleted(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, store.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +140,119 @@ func (b *boards) Get(ctx context.Context, id uuid.UUID) (*models.Board, error) {
 	return &board, err
 }
 
+func (b *boards) Update(ctx context.Context, id uuid.UUID, mutate func(*models.Board) error) (*models.Board, error) {
+	ctx, done := track(ctx, "boards", "Update")
+	defer done()
+	key := b.key(id)
+	for range maxUpdateAttempts {
+		entry, err := b.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, store.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		var board models.Board
+		if err = json.Unmarshal(entry.Value(), &board); err != nil {
+			return nil, err
+		}
+		if err := mutate(&board); err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(board)
+		if err != nil {
+			return nil, err
+		}
+		_, err = b.kv.Update(ctx, key, val, entry.Revision())
+		if isWrongRevision(err) {
+			continue // updated by someone else in the meantime, try again
+		}
+		if err != nil {
+			return nil, err
+		}
+		// index expires along with the board
+		if _, err := b.kv.Put(ctx, b.facilitatorKey(board.FacilitatorID, board.ID), nil); err != nil {
+			return nil, err
+		}
+		return &board, nil
+	}
+	return nil, fmt.Errorf("board %s modified concurrently, gave up after %d attempts", id, maxUpdateAttempts)
+}
+
+func (b *boards) passcodeKey(id uuid.UUID) string {
+	return fmt.Sprintf("boards.%s.passcode", id)
+}
+
+func (b *boards) GetPasscode(ctx context.Context, id uuid.UUID) (string, error) {
+	ctx, done := track(ctx, "boards", "GetPasscode")
+	defer done()
+	val, err := b.kv.Get(ctx, b.passcodeKey(id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(val.Value()), nil
+}
+
+func (b *boards) SetPasscode(ctx context.Context, id uuid.UUID, hash string) error {
+	ctx, done := track(ctx, "boards", "SetPasscode")
+	defer done()
+	if hash == "" {
+		return b.kv.Delete(ctx, b.passcodeKey(id))
+	}
+	_, err := b.kv.Put(ctx, b.passcodeKey(id), []byte(hash))
+	return err
+}
+
+// Delete purges the board along with all its records, as well as deliveries of its webhooks.
+// Tombstone is put first so the board isn't created again, e.g by someone opening its link while it's being deleted.
+// The board is purged last so deleting it again finishes the job when it failed halfway.
 func (b *boards) Delete(ctx context.Context, id uuid.UUID) error {
-	return b.kv.Delete(ctx, b.key(id))
+	ctx, done := track(ctx, "boards", "Delete")
+	defer done()
+	if _, err := b.kv.Put(ctx, b.tombstoneKey(id), nil); err != nil {
+		return err
+	}
+	keys, err := b.listKeys(ctx, fmt.Sprintf("boards.%s.>", id))
+	if err != nil {
+		return err
+	}
+	board, err := b.Get(ctx, id)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if board != nil {
+		keys = append(keys, b.facilitatorKey(board.FacilitatorID, id))
+	}
+	for _, key := range keys {
+		// boards.<id>.webhooks.<webhook id>
+		if tokens := strings.Split(key, "."); len(tokens) == 4 && tokens[2] == "webhooks" {
+			deliveries, err := b.listKeys(ctx, fmt.Sprintf("webhooks.%s.deliveries.*", tokens[3]))
+			if err != nil {
+				return err
+			}
+			keys = append(keys, deliveries...)
+		}
+	}
+	for _, key := range keys {
+		if err := b.kv.Purge(ctx, key); err != nil {
+			return fmt.Errorf("error purging %s: %w", key, err)
+		}
+	}
+	return b.kv.Purge(ctx, b.key(id))
+}
+
+// listKeys returns all keys matching the filter
+func (b *boards) listKeys(ctx context.Context, filter string) ([]string, error) {
+	lister, err := b.kv.ListKeysFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+	}
+	return keys, nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go/jetstream"
 )
@@ -19,7 +20,29 @@ func (u *cards) key(boardID, id uuid.UUID) string {
 	return fmt.Sprintf("boards.%s.cards.%s", boardID, id)
 }
 
+func (c *cards) ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([]string, error) {
+	ctx, done := track(ctx, "cards", "ListKeys")
+	defer done()
+	var keys []string
+	lister, err := c.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.cards.*", boardID))
+	if err != nil {
+		return nil, err
+	}
+
+	counter := 0
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+		counter++
+		if counter >= limit {
+			lister.Stop()
+		}
+	}
+	return keys, nil
+}
+
 func (c *cards) List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.Card, error) {
+	ctx, done := track(ctx, "cards", "List")
+	defer done()
 	var cards []models.Card
 	lister, err := c.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.cards.*", boardID))
 	if err != nil {
@@ -46,6 +69,8 @@ func (c *cards) List(ctx context.Context, boardID uuid.UUID, limit int) ([]model
 }
 
 func (c *cards) Create(ctx context.Context, card models.Card) error {
+	ctx, done := track(ctx, "cards", "Create")
+	defer done()
 	key := c.key(card.BoardID, card.ID)
 	_, err := c.kv.Get(ctx, key)
 	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -60,8 +85,13 @@ func (c *cards) Create(ctx context.Context, card models.Card) error {
 }
 
 func (c *cards) Get(ctx context.Context, boardID, id uuid.UUID) (*models.Card, error) {
+	ctx, done := track(ctx, "cards", "Get")
+	defer done()
 	key := c.key(boardID, id)
 	val, err := c.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, store.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -70,16 +100,58 @@ func (c *cards) Get(ctx context.Context, boardID, id uuid.UUID) (*models.Card, e
 	return &card, err
 }
 
-func (c *cards) Update(ctx context.Context, card models.Card) error {
-	b, err := json.Marshal(card)
-	if err != nil {
-		return err
-	}
+func (c *cards) Update(ctx context.Context, boardID, id uuid.UUID, mutate func(*models.Card) error) (*models.Card, error) {
+	ctx, done := track(ctx, "cards", "Update")
+	defer done()
+	return c.update(ctx, boardID, id, mutate)
+}
 
-	_, err = c.kv.Put(ctx, c.key(card.BoardID, card.ID), b)
-	return err
+func (c *cards) Vote(ctx context.Context, boardID, id uuid.UUID, delta int) (*models.Card, error) {
+	ctx, done := track(ctx, "cards", "Vote")
+	defer done()
+	return c.update(ctx, boardID, id, func(card *models.Card) error {
+		card.Votes += delta
+		return nil
+	})
+}
+
+// update applies mutate to the latest card and stores it with revision check,
+// it's retried when the card was changed by someone else in the meantime.
+func (c *cards) update(ctx context.Context, boardID, id uuid.UUID, mutate func(*models.Card) error) (*models.Card, error) {
+	key := c.key(boardID, id)
+	for range maxUpdateAttempts {
+		entry, err := c.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			return nil, store.ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		var card models.Card
+		if err = json.Unmarshal(entry.Value(), &card); err != nil {
+			return nil, err
+		}
+		if err := mutate(&card); err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(card)
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.kv.Update(ctx, key, val, entry.Revision())
+		if isWrongRevision(err) {
+			continue // changed by someone else in the meantime, try again
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &card, nil
+	}
+	return nil, fmt.Errorf("card %s modified concurrently, gave up after %d attempts", id, maxUpdateAttempts)
 }
 
 func (c *cards) Delete(ctx context.Context, boardID, id uuid.UUID) error {
+	ctx, done := track(ctx, "cards", "Delete")
+	defer done()
 	return c.kv.Delete(ctx, c.key(boardID, id))
 }
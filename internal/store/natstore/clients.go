@@ -19,7 +19,29 @@ func (c *clients) key(boardID, id uuid.UUID) string {
 	return fmt.Sprintf("boards.%s.clients.%s", boardID, id)
 }
 
+func (c *clients) ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([]string, error) {
+	ctx, done := track(ctx, "clients", "ListKeys")
+	defer done()
+	var keys []string
+	lister, err := c.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.clients.*", boardID))
+	if err != nil {
+		return nil, err
+	}
+
+	counter := 0
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+		counter++
+		if counter >= limit {
+			lister.Stop()
+		}
+	}
+	return keys, nil
+}
+
 func (c *clients) Create(ctx context.Context, client models.Client) error {
+	ctx, done := track(ctx, "clients", "Create")
+	defer done()
 	key := c.key(client.BoardID, client.ID)
 	_, err := c.kv.Get(ctx, key)
 	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -34,5 +56,7 @@ func (c *clients) Create(ctx context.Context, client models.Client) error {
 }
 
 func (c *clients) Delete(ctx context.Context, boardID, id uuid.UUID) error {
+	ctx, done := track(ctx, "clients", "Delete")
+	defer done()
 	return c.kv.Delete(ctx, c.key(boardID, id))
 }
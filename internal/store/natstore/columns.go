@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go/jetstream"
 )
@@ -20,6 +21,8 @@ func (u *columns) key(boardID, id uuid.UUID) string {
 }
 
 func (c *columns) ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([]string, error) {
+	ctx, done := track(ctx, "columns", "ListKeys")
+	defer done()
 	var keys []string
 	lister, err := c.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.columns.*", boardID))
 	if err != nil {
@@ -38,6 +41,8 @@ func (c *columns) ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([
 }
 
 func (c *columns) List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.Column, error) {
+	ctx, done := track(ctx, "columns", "List")
+	defer done()
 	var columns []models.Column
 	lister, err := c.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.columns.*", boardID))
 	if err != nil {
@@ -65,6 +70,8 @@ func (c *columns) List(ctx context.Context, boardID uuid.UUID, limit int) ([]mod
 }
 
 func (c *columns) Create(ctx context.Context, column models.Column) error {
+	ctx, done := track(ctx, "columns", "Create")
+	defer done()
 	key := c.key(column.BoardID, column.ID)
 	_, err := c.kv.Get(ctx, key)
 	if err != nil && !errors.Is(err, jetstream.ErrKeyNotFound) {
@@ -79,8 +86,13 @@ func (c *columns) Create(ctx context.Context, column models.Column) error {
 }
 
 func (c *columns) Get(ctx context.Context, boardID, id uuid.UUID) (*models.Column, error) {
+	ctx, done := track(ctx, "columns", "Get")
+	defer done()
 	key := c.key(boardID, id)
 	val, err := c.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, store.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -90,6 +102,8 @@ func (c *columns) Get(ctx context.Context, boardID, id uuid.UUID) (*models.Colum
 }
 
 func (c *columns) Update(ctx context.Context, column models.Column) error {
+	ctx, done := track(ctx, "columns", "Update")
+	defer done()
 	b, err := json.Marshal(column)
 	if err != nil {
 		return err
@@ -100,5 +114,7 @@ func (c *columns) Update(ctx context.Context, column models.Column) error {
 }
 
 func (c *columns) Delete(ctx context.Context, boardID, id uuid.UUID) error {
+	ctx, done := track(ctx, "columns", "Delete")
+	defer done()
 	return c.kv.Delete(ctx, c.key(boardID, id))
 }
@@ -0,0 +1,57 @@
+package natstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// leases stored in separate bucket where keys expire after store.LeaseTTL,
+// so a lease is released automatically when its owner stops refreshing it.
+type leases struct {
+	kv jetstream.KeyValue
+}
+
+func (l *leases) Acquire(ctx context.Context, key, owner string) (uint64, error) {
+	ctx, done := track(ctx, "leases", "Acquire")
+	defer done()
+	rev, err := l.kv.Create(ctx, key, []byte(owner))
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return 0, store.ErrLeaseTaken
+	}
+	return rev, err
+}
+
+func (l *leases) Refresh(ctx context.Context, key, owner string, revision uint64) (uint64, error) {
+	ctx, done := track(ctx, "leases", "Refresh")
+	defer done()
+	rev, err := l.kv.Update(ctx, key, []byte(owner), revision)
+	if isWrongRevision(err) {
+		return 0, store.ErrLeaseTaken
+	}
+	return rev, err
+}
+
+func (l *leases) Release(ctx context.Context, key string, revision uint64) error {
+	ctx, done := track(ctx, "leases", "Release")
+	defer done()
+	return l.kv.Delete(ctx, key, jetstream.LastRevision(revision))
+}
+
+func (l *leases) Keys(ctx context.Context) ([]string, error) {
+	ctx, done := track(ctx, "leases", "Keys")
+	defer done()
+	lister, err := l.kv.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var keys []string
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -7,15 +7,39 @@ package natstore
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/ekaputra07/go-retro/internal/metrics"
 	"github.com/ekaputra07/go-retro/internal/natsutil"
 	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/tracing"
 	"github.com/nats-io/nats.go/jetstream"
 )
 
 const TTL = 2 * time.Hour // only available for 2hrs since creation
 
+// Maximum attempts of optimistic update when the key keeps being modified concurrently.
+const maxUpdateAttempts = 10
+
+// isWrongRevision reports whether err caused by the key modified since the expected revision
+func isWrongRevision(err error) bool {
+	var apiErr *jetstream.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode == jetstream.JSErrCodeStreamWrongLastSequence
+}
+
+// track starts tracing span and measuring latency of store operation,
+// call the returned func when the operation done.
+func track(ctx context.Context, repo, method string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("store.%s.%s", repo, method))
+	return ctx, func() {
+		span.End()
+		metrics.StoreOperationDuration.WithLabelValues(repo, method).Observe(time.Since(start).Seconds())
+	}
+}
+
 func getKV(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstream.KeyValue, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
@@ -27,16 +51,84 @@ func getKV(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstrea
 	})
 }
 
+// getLeaseKV returns bucket for leases, where keys expire unless refreshed
+func getLeaseKV(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstream.KeyValue, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return nats.JS.CreateOrUpdateKeyValue(timeoutCtx, jetstream.KeyValueConfig{
+		Bucket: fmt.Sprintf("%s-leases", namespace),
+		TTL:    store.LeaseTTL,
+	})
+}
+
+// getBlobStore returns object store for blobs, they expire along with other records
+func getBlobStore(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstream.ObjectStore, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return nats.JS.CreateOrUpdateObjectStore(timeoutCtx, jetstream.ObjectStoreConfig{
+		Bucket:   fmt.Sprintf("%s-blobs", namespace),
+		TTL:      TTL,
+		MaxBytes: 1024 * 1000 * 100, // 100Mb
+	})
+}
+
+// getTokenKV returns bucket for API tokens, they're kept until they expire
+func getTokenKV(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstream.KeyValue, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return nats.JS.CreateOrUpdateKeyValue(timeoutCtx, jetstream.KeyValueConfig{
+		Bucket: fmt.Sprintf("%s-tokens", namespace),
+		TTL:    store.MaxAPITokenTTL,
+	})
+}
+
+// getTicketKV returns bucket for used websocket tickets, they're forgotten once the tickets expired
+func getTicketKV(ctx context.Context, nats *natsutil.NATS, namespace string) (jetstream.KeyValue, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return nats.JS.CreateOrUpdateKeyValue(timeoutCtx, jetstream.KeyValueConfig{
+		Bucket: fmt.Sprintf("%s-tickets", namespace),
+		TTL:    store.TicketTTL,
+	})
+}
+
 func NewStore(ctx context.Context, nats *natsutil.NATS, namespace string) (*store.Store, error) {
 	kv, err := getKV(ctx, nats, namespace)
 	if err != nil {
 		return nil, err
 	}
+	leaseKV, err := getLeaseKV(ctx, nats, namespace)
+	if err != nil {
+		return nil, err
+	}
+	blobStore, err := getBlobStore(ctx, nats, namespace)
+	if err != nil {
+		return nil, err
+	}
+	tokenKV, err := getTokenKV(ctx, nats, namespace)
+	if err != nil {
+		return nil, err
+	}
+	ticketKV, err := getTicketKV(ctx, nats, namespace)
+	if err != nil {
+		return nil, err
+	}
 	return &store.Store{
-		Clients: &clients{kv},
-		Users:   &users{kv},
-		Boards:  &boards{kv},
-		Columns: &columns{kv},
-		Cards:   &cards{kv},
+		Clients:      &clients{kv},
+		Users:        &users{kv},
+		Boards:       &boards{kv},
+		Columns:      &columns{kv},
+		Cards:        &cards{kv},
+		Timers:       &timers{kv},
+		Leases:       &leases{leaseKV},
+		Blobs:        &blobs{blobStore},
+		Tokens:       &tokens{tokenKV},
+		Webhooks:     &webhooks{kv},
+		Tickets:      &tickets{ticketKV},
+		Participants: &participants{kv},
 	}, nil
 }
@@ -0,0 +1,72 @@
+package natstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type participants struct {
+	kv jetstream.KeyValue
+}
+
+func (p *participants) key(boardID, userID uuid.UUID) string {
+	return fmt.Sprintf("boards.%s.participants.%s", boardID, userID)
+}
+
+// Put adds the user to participants of the board, or updates them (e.g name changed)
+func (p *participants) Put(ctx context.Context, boardID uuid.UUID, user models.User) error {
+	ctx, done := track(ctx, "participants", "Put")
+	defer done()
+	val, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	_, err = p.kv.Put(ctx, p.key(boardID, user.ID), val)
+	return err
+}
+
+func (p *participants) Has(ctx context.Context, boardID, userID uuid.UUID) (bool, error) {
+	ctx, done := track(ctx, "participants", "Has")
+	defer done()
+	_, err := p.kv.Get(ctx, p.key(boardID, userID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *participants) List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.User, error) {
+	ctx, done := track(ctx, "participants", "List")
+	defer done()
+	lister, err := p.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.participants.*", boardID))
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var list []models.User
+	for key := range lister.Keys() {
+		val, err := p.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue // expired in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		var user models.User
+		if err := json.Unmarshal(val.Value(), &user); err != nil {
+			return nil, err
+		}
+		list = append(list, user)
+		if len(list) >= limit {
+			break
+		}
+	}
+	return list, nil
+}
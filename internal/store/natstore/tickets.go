@@ -0,0 +1,25 @@
+package natstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// tickets stored in separate bucket where keys expire after store.TicketTTL
+type tickets struct {
+	kv jetstream.KeyValue
+}
+
+func (t *tickets) Use(ctx context.Context, id uuid.UUID) error {
+	ctx, done := track(ctx, "tickets", "Use")
+	defer done()
+	_, err := t.kv.Create(ctx, id.String(), nil)
+	if errors.Is(err, jetstream.ErrKeyExists) {
+		return store.ErrTicketUsed
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package natstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type timers struct {
+	kv jetstream.KeyValue
+}
+
+func (t *timers) key(boardID uuid.UUID) string {
+	return fmt.Sprintf("boards.%s.timer", boardID)
+}
+
+func (t *timers) ListBoardIDs(ctx context.Context, limit int) ([]uuid.UUID, error) {
+	ctx, done := track(ctx, "timers", "ListBoardIDs")
+	defer done()
+	lister, err := t.kv.ListKeysFiltered(ctx, "boards.*.timer")
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var ids []uuid.UUID
+	for key := range lister.Keys() {
+		// boards.<id>.timer
+		id, err := uuid.Parse(strings.Split(key, ".")[1])
+		if err != nil {
+			continue // skip
+		}
+		ids = append(ids, id)
+		if len(ids) >= limit {
+			break
+		}
+	}
+	return ids, nil
+}
+
+func (t *timers) Get(ctx context.Context, boardID uuid.UUID) (*models.Timer, error) {
+	ctx, done := track(ctx, "timers", "Get")
+	defer done()
+	val, err := t.kv.Get(ctx, t.key(boardID))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var timer models.Timer
+	err = json.Unmarshal(val.Value(), &timer)
+	return &timer, err
+}
+
+func (t *timers) Put(ctx context.Context, timer models.Timer) error {
+	ctx, done := track(ctx, "timers", "Put")
+	defer done()
+	b, err := json.Marshal(timer)
+	if err != nil {
+		return err
+	}
+	_, err = t.kv.Put(ctx, t.key(timer.BoardID), b)
+	return err
+}
+
+func (t *timers) Delete(ctx context.Context, boardID uuid.UUID) error {
+	ctx, done := track(ctx, "timers", "Delete")
+	defer done()
+	return t.kv.Delete(ctx, t.key(boardID))
+}
@@ -0,0 +1,92 @@
+package natstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// tokenRecord is the stored token, including its hash which is never sent to clients
+type tokenRecord struct {
+	models.APIToken
+	Hash string `json:"hash"`
+}
+
+type tokens struct {
+	kv jetstream.KeyValue
+}
+
+func (t *tokens) key(userID, id uuid.UUID) string {
+	return fmt.Sprintf("%s.%s", userID, id)
+}
+
+func (t *tokens) Create(ctx context.Context, token models.APIToken) error {
+	ctx, done := track(ctx, "tokens", "Create")
+	defer done()
+	val, err := json.Marshal(tokenRecord{APIToken: token, Hash: token.Hash})
+	if err != nil {
+		return err
+	}
+	_, err = t.kv.Create(ctx, t.key(token.UserID, token.ID), val)
+	return err
+}
+
+func (t *tokens) Get(ctx context.Context, userID, id uuid.UUID) (*models.APIToken, error) {
+	ctx, done := track(ctx, "tokens", "Get")
+	defer done()
+	val, err := t.kv.Get(ctx, t.key(userID, id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record tokenRecord
+	if err := json.Unmarshal(val.Value(), &record); err != nil {
+		return nil, err
+	}
+	record.APIToken.Hash = record.Hash
+	return &record.APIToken, nil
+}
+
+func (t *tokens) List(ctx context.Context, userID uuid.UUID, limit int) ([]models.APIToken, error) {
+	ctx, done := track(ctx, "tokens", "List")
+	defer done()
+	lister, err := t.kv.ListKeysFiltered(ctx, fmt.Sprintf("%s.*", userID))
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var list []models.APIToken
+	for key := range lister.Keys() {
+		val, err := t.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue // deleted in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record tokenRecord
+		if err := json.Unmarshal(val.Value(), &record); err != nil {
+			return nil, err
+		}
+		list = append(list, record.APIToken)
+		if len(list) >= limit {
+			break
+		}
+	}
+	return list, nil
+}
+
+func (t *tokens) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	ctx, done := track(ctx, "tokens", "Delete")
+	defer done()
+	return t.kv.Delete(ctx, t.key(userID, id))
+}
@@ -19,6 +19,8 @@ func (u *users) key(id uuid.UUID) string {
 }
 
 func (u *users) Create(ctx context.Context, user models.User) error {
+	ctx, done := track(ctx, "users", "Create")
+	defer done()
 	b, err := json.Marshal(user)
 	if err != nil {
 		return err
@@ -28,6 +30,8 @@ func (u *users) Create(ctx context.Context, user models.User) error {
 }
 
 func (u *users) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	ctx, done := track(ctx, "users", "Get")
+	defer done()
 	val, err := u.kv.Get(ctx, u.key(id))
 	if err != nil {
 		return nil, err
@@ -39,6 +43,8 @@ func (u *users) Get(ctx context.Context, id uuid.UUID) (*models.User, error) {
 }
 
 func (u *users) Update(ctx context.Context, user models.User) error {
+	ctx, done := track(ctx, "users", "Update")
+	defer done()
 	b, err := json.Marshal(user)
 	if err != nil {
 		return err
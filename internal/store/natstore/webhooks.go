@@ -0,0 +1,173 @@
+package natstore
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// webhookRecord is the stored webhook, including its secret which is never sent to clients
+type webhookRecord struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// maxDeliveries is the number of latest deliveries kept for each webhook, older ones are deleted
+const maxDeliveries = 100
+
+type webhooks struct {
+	kv jetstream.KeyValue
+}
+
+func (w *webhooks) key(boardID, id uuid.UUID) string {
+	return fmt.Sprintf("boards.%s.webhooks.%s", boardID, id)
+}
+
+func (w *webhooks) deliveryKey(webhookID, id uuid.UUID) string {
+	return fmt.Sprintf("webhooks.%s.deliveries.%s", webhookID, id)
+}
+
+func (w *webhooks) List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.Webhook, error) {
+	ctx, done := track(ctx, "webhooks", "List")
+	defer done()
+	lister, err := w.kv.ListKeysFiltered(ctx, fmt.Sprintf("boards.%s.webhooks.*", boardID))
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var list []models.Webhook
+	for key := range lister.Keys() {
+		val, err := w.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue // deleted in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record webhookRecord
+		if err := json.Unmarshal(val.Value(), &record); err != nil {
+			return nil, err
+		}
+		record.Webhook.Secret = record.Secret
+		list = append(list, record.Webhook)
+		if len(list) >= limit {
+			break
+		}
+	}
+	return list, nil
+}
+
+func (w *webhooks) Create(ctx context.Context, webhook models.Webhook) error {
+	ctx, done := track(ctx, "webhooks", "Create")
+	defer done()
+	val, err := json.Marshal(webhookRecord{Webhook: webhook, Secret: webhook.Secret})
+	if err != nil {
+		return err
+	}
+	_, err = w.kv.Create(ctx, w.key(webhook.BoardID, webhook.ID), val)
+	return err
+}
+
+func (w *webhooks) Get(ctx context.Context, boardID, id uuid.UUID) (*models.Webhook, error) {
+	ctx, done := track(ctx, "webhooks", "Get")
+	defer done()
+	val, err := w.kv.Get(ctx, w.key(boardID, id))
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record webhookRecord
+	if err := json.Unmarshal(val.Value(), &record); err != nil {
+		return nil, err
+	}
+	record.Webhook.Secret = record.Secret
+	return &record.Webhook, nil
+}
+
+func (w *webhooks) Delete(ctx context.Context, boardID, id uuid.UUID) error {
+	ctx, done := track(ctx, "webhooks", "Delete")
+	defer done()
+	return w.kv.Delete(ctx, w.key(boardID, id))
+}
+
+func (w *webhooks) AddDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	ctx, done := track(ctx, "webhooks", "AddDelivery")
+	defer done()
+	val, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+	if _, err = w.kv.Put(ctx, w.deliveryKey(delivery.WebhookID, delivery.ID), val); err != nil {
+		return err
+	}
+
+	keys, err := w.deliveryKeys(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+	for len(keys) > maxDeliveries {
+		if err := w.kv.Delete(ctx, keys[0]); err != nil {
+			return err
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
+
+// deliveryKeys returns delivery keys of the webhook, oldest first.
+// Keys are listed in the order they were written, deliveries are never updated.
+func (w *webhooks) deliveryKeys(ctx context.Context, webhookID uuid.UUID) ([]string, error) {
+	lister, err := w.kv.ListKeysFiltered(ctx, fmt.Sprintf("webhooks.%s.deliveries.*", webhookID))
+	if err != nil {
+		return nil, err
+	}
+	defer lister.Stop()
+
+	var keys []string
+	for key := range lister.Keys() {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (w *webhooks) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	ctx, done := track(ctx, "webhooks", "ListDeliveries")
+	defer done()
+	keys, err := w.deliveryKeys(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []models.WebhookDelivery
+	for _, key := range slices.Backward(keys) {
+		val, err := w.kv.Get(ctx, key)
+		if errors.Is(err, jetstream.ErrKeyNotFound) {
+			continue // expired in the meantime
+		}
+		if err != nil {
+			return nil, err
+		}
+		var d models.WebhookDelivery
+		if err := json.Unmarshal(val.Value(), &d); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+		if len(list) >= limit {
+			break
+		}
+	}
+	// written concurrently by other instances, order by time they were made
+	slices.SortStableFunc(list, func(x, y models.WebhookDelivery) int { return cmp.Compare(y.CreatedAt, x.CreatedAt) })
+	return list, nil
+}
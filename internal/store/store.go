@@ -2,6 +2,9 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/ekaputra07/go-retro/internal/models"
 	"github.com/google/uuid"
@@ -14,14 +17,29 @@ type UserRepo interface {
 }
 
 type ClientRepo interface {
+	ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([]string, error)
 	Create(ctx context.Context, client models.Client) error
 	Delete(ctx context.Context, boardID uuid.UUID, id uuid.UUID) error
 }
 type BoardRepo interface {
 	List(ctx context.Context, limit int) ([]models.Board, error)
+	// ListByFacilitator returns boards facilitated by the user
+	ListByFacilitator(ctx context.Context, facilitatorID uuid.UUID, limit int) ([]models.Board, error)
+	// Create creates the board, returns ErrExists when it was created already
 	Create(ctx context.Context, board models.Board) error
 	Get(ctx context.Context, id uuid.UUID) (*models.Board, error)
+	// Update applies mutate to the latest board and stores it unless the board changed in the meantime,
+	// then mutate is applied again. Error returned by mutate aborts the update.
+	Update(ctx context.Context, id uuid.UUID, mutate func(*models.Board) error) (*models.Board, error)
+	// Delete deletes the board along with its clients, columns, cards and the rest of its records.
+	// Getting or creating the board afterwards returns ErrDeleted.
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// GetPasscode returns passcode hash of the board, empty when it has none.
+	// It's stored apart from the board so it's never sent to clients.
+	GetPasscode(ctx context.Context, id uuid.UUID) (string, error)
+	// SetPasscode sets passcode hash of the board, empty hash removes the passcode.
+	SetPasscode(ctx context.Context, id uuid.UUID, hash string) error
 }
 
 type ColumnRepo interface {
@@ -34,18 +52,123 @@ type ColumnRepo interface {
 }
 
 type CardRepo interface {
+	ListKeys(ctx context.Context, boardID uuid.UUID, limit int) ([]string, error)
 	List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.Card, error)
 	Create(ctx context.Context, card models.Card) error
 	Get(ctx context.Context, boardID uuid.UUID, id uuid.UUID) (*models.Card, error)
-	Update(ctx context.Context, card models.Card) error
+	// Update applies mutate to the latest card and stores it unless the card changed in the meantime,
+	// then mutate is applied again. Error returned by mutate aborts the update.
+	Update(ctx context.Context, boardID uuid.UUID, id uuid.UUID, mutate func(*models.Card) error) (*models.Card, error)
+	// Vote adds delta to card votes atomically, so concurrent votes are not lost
+	Vote(ctx context.Context, boardID uuid.UUID, id uuid.UUID, delta int) (*models.Card, error)
 	Delete(ctx context.Context, boardID uuid.UUID, id uuid.UUID) error
 }
 
+type TimerRepo interface {
+	// ListBoardIDs returns IDs of boards with persisted timer
+	ListBoardIDs(ctx context.Context, limit int) ([]uuid.UUID, error)
+	Get(ctx context.Context, boardID uuid.UUID) (*models.Timer, error)
+	Put(ctx context.Context, timer models.Timer) error
+	Delete(ctx context.Context, boardID uuid.UUID) error
+}
+
+// Blob is binary data e.g uploaded image, along with its content type
+type Blob struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+}
+
+// BlobRepo stores blobs by key, they're too large to be stored along with other records
+type BlobRepo interface {
+	Put(ctx context.Context, key string, blob Blob) error
+	Get(ctx context.Context, key string) (*Blob, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// TokenRepo stores API tokens of users
+type TokenRepo interface {
+	Create(ctx context.Context, token models.APIToken) error
+	Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*models.APIToken, error)
+	List(ctx context.Context, userID uuid.UUID, limit int) ([]models.APIToken, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
+
+// WebhookRepo stores webhooks of boards along with log of their deliveries
+type WebhookRepo interface {
+	List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.Webhook, error)
+	Create(ctx context.Context, webhook models.Webhook) error
+	Get(ctx context.Context, boardID uuid.UUID, id uuid.UUID) (*models.Webhook, error)
+	Delete(ctx context.Context, boardID uuid.UUID, id uuid.UUID) error
+
+	AddDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+	// ListDeliveries returns the latest deliveries of webhook, newest first
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+}
+
+// ParticipantRepo stores users who joined a board, they're kept after they left so the board summary can list them
+type ParticipantRepo interface {
+	Put(ctx context.Context, boardID uuid.UUID, user models.User) error
+	// Has returns whether the user joined the board as participant
+	Has(ctx context.Context, boardID uuid.UUID, userID uuid.UUID) (bool, error)
+	List(ctx context.Context, boardID uuid.UUID, limit int) ([]models.User, error)
+}
+
+// TicketRepo records used websocket tickets so each can be used only once
+type TicketRepo interface {
+	// Use marks the ticket used, returns ErrTicketUsed when it was used already
+	Use(ctx context.Context, id uuid.UUID) error
+}
+
+// MaxAPITokenTTL is the longest an API token can be valid
+const MaxAPITokenTTL = 365 * 24 * time.Hour
+
+// TicketTTL is how long used tickets are remembered, tickets must expire sooner
+const TicketTTL = 5 * time.Minute
+
+// LeaseTTL is how long a lease is valid unless refreshed by its owner
+const LeaseTTL = 10 * time.Second
+
+var (
+	// ErrNotFound returned when record not found
+	ErrNotFound = errors.New("not found")
+
+	// ErrDeleted returned when board was deleted, it's also ErrNotFound.
+	// Deleted board can't be created again until the records expire.
+	ErrDeleted = fmt.Errorf("%w: deleted", ErrNotFound)
+
+	// ErrExists returned when record was created already
+	ErrExists = errors.New("already exists")
+
+	// ErrTicketUsed returned when ticket was used already
+	ErrTicketUsed = errors.New("ticket used")
+
+	// ErrLeaseTaken returned when lease is owned by other owner
+	ErrLeaseTaken = errors.New("lease taken")
+)
+
+// LeaseRepo provides exclusive ownership of a key across instances,
+// each operation returns revision that must be passed to the next operation.
+type LeaseRepo interface {
+	Acquire(ctx context.Context, key, owner string) (uint64, error)
+	Refresh(ctx context.Context, key, owner string, revision uint64) (uint64, error)
+	Release(ctx context.Context, key string, revision uint64) error
+	// Keys returns keys of the leases currently owned
+	Keys(ctx context.Context) ([]string, error)
+}
+
 // Store stores globally available records e.g Users and Boards
 type Store struct {
-	Users   UserRepo
-	Boards  BoardRepo
-	Columns ColumnRepo
-	Cards   CardRepo
-	Clients ClientRepo
+	Users        UserRepo
+	Boards       BoardRepo
+	Columns      ColumnRepo
+	Cards        CardRepo
+	Clients      ClientRepo
+	Timers       TimerRepo
+	Leases       LeaseRepo
+	Blobs        BlobRepo
+	Tokens       TokenRepo
+	Webhooks     WebhookRepo
+	Tickets      TicketRepo
+	Participants ParticipantRepo
 }
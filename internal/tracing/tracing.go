@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/ekaputra07/go-retro"
+
+// Config holds OTLP exporter configuration
+type Config struct {
+	Endpoint    string // OTLP HTTP endpoint (host:port), tracing is disabled when empty
+	Insecure    bool
+	SampleRatio float64
+	ServiceName string
+	Version     string
+}
+
+// Setup configures global tracer provider and propagator.
+// When endpoint is empty the default no-op tracer provider is kept.
+// Returned shutdown func flushes remaining spans and must be called before exit.
+func Setup(ctx context.Context, conf Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if conf.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(conf.Endpoint)}
+	if conf.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(conf.ServiceName),
+		semconv.ServiceVersion(conf.Version),
+	))
+	if err != nil && !errors.Is(err, resource.ErrSchemaURLConflict) {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a new span using the app tracer
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// End records err (if any) to the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// BoardID returns board ID span attribute
+func BoardID(id string) attribute.KeyValue {
+	return attribute.String("goretro.board.id", id)
+}
+
+// headerCarrier adapts nats.Header to propagation.TextMapCarrier
+type headerCarrier nats.Header
+
+func (h headerCarrier) Get(key string) string {
+	return nats.Header(h).Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	nats.Header(h).Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Inject injects trace context from ctx into NATS message header
+func Inject(ctx context.Context, msg *nats.Msg) {
+	if msg.Header == nil {
+		msg.Header = nats.Header{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier(msg.Header))
+}
+
+// Extract returns a copy of ctx with trace context from NATS message header
+func Extract(ctx context.Context, msg *nats.Msg) context.Context {
+	if msg.Header == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, headerCarrier(msg.Header))
+}
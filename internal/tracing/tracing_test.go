@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/natsutil/natstest"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectExtract(t *testing.T) {
+	srv := natstest.Server(t)
+	_, err := Setup(context.Background(), Config{})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	nc, err := nats.Connect(srv.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("tracing.test")
+	require.NoError(t, err)
+
+	ctx, span := Start(context.Background(), "publish")
+	msg := &nats.Msg{Subject: "tracing.test", Data: []byte("hi")}
+	Inject(ctx, msg)
+	require.NoError(t, nc.PublishMsg(msg))
+	End(span, nil)
+
+	received, err := sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	_, consumer := Start(Extract(context.Background(), received), "consume")
+	End(consumer, nil)
+
+	// consumer span continues the publisher trace
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, span.SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.True(t, spans[1].Parent().IsRemote())
+}
+
+func TestExtract_noHeader(t *testing.T) {
+	ctx := Extract(context.Background(), &nats.Msg{Subject: "tracing.test"})
+	assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+}
@@ -0,0 +1,102 @@
+// Package webhook delivers board events to webhooks as signed JSON requests.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/google/uuid"
+)
+
+// Headers of webhook requests
+const (
+	EventHeader     = "X-Goretro-Event"
+	DeliveryHeader  = "X-Goretro-Delivery" // event ID, the same on retries
+	TimestampHeader = "X-Goretro-Timestamp"
+	SignatureHeader = "X-Goretro-Signature"
+)
+
+const (
+	// MaxPerBoard is the maximum number of webhooks a board can have.
+	MaxPerBoard = 5
+
+	// Maximum length of webhook URL.
+	maxURLLength = 2048
+)
+
+// ErrInvalidWebhook returned when webhook URL or events are not valid
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// New returns new webhook of the board with generated secret, events empty means all events
+func New(boardID uuid.UUID, rawURL string, events []string) (models.Webhook, error) {
+	if err := ValidateURL(rawURL); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := ValidateEvents(events); err != nil {
+		return models.Webhook{}, err
+	}
+	events = slices.Compact(slices.Sorted(slices.Values(events)))
+	if events == nil {
+		events = []string{}
+	}
+	return models.Webhook{
+		ID:        uuid.New(),
+		BoardID:   boardID,
+		URL:       rawURL,
+		Events:    events,
+		Secret:    rand.Text(),
+		CreatedAt: time.Now().Unix(),
+	}, nil
+}
+
+// ValidateURL checks that webhook URL is absolute http(s) URL without credentials
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || len(rawURL) > maxURLLength || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: url must be http or https URL", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// ValidateEvents checks that events are known event types
+func ValidateEvents(events []string) error {
+	for _, e := range events {
+		if !slices.Contains(models.EventTypes, e) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+	}
+	return nil
+}
+
+// subscribed returns whether webhook receives event of given type
+func subscribed(w models.Webhook, typ string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, typ)
+}
+
+// Sign returns signature of webhook request body sent at timestamp (unix seconds).
+// Receivers compute it the same way with the webhook secret and compare with SignatureHeader,
+// and should reject old timestamps so requests can't be replayed.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicAddr returns whether ip is a public address, board webhooks can't reach internal services
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
@@ -0,0 +1,379 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/board"
+	"github.com/ekaputra07/go-retro/internal/metrics"
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/tracing"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// Stream of board events and webhook deliveries, messages removed once processed.
+	streamName   = "goretro-webhooks"
+	streamMaxAge = 24 * time.Hour
+
+	// Durable consumers shared by all instances, so each event is only processed once.
+	dispatchConsumer = "webhooks-dispatch"
+	deliverConsumer  = "webhooks-deliver"
+
+	// jobTopics matches delivery job topic of all webhooks
+	jobTopics = "webhooks.jobs.>"
+
+	// Time limit of each webhook request.
+	deliveryTimeout = 10 * time.Second
+
+	// Maximum number of webhook requests in flight per instance.
+	maxConcurrentDeliveries = 8
+
+	// Maximum size of webhook response body read, the rest is ignored.
+	maxResponseSize = 64 << 10
+)
+
+// DefaultBackoff is delays between delivery retries, delivery fails after all of them.
+var DefaultBackoff = []time.Duration{10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// errForbiddenAddress returned when board webhook resolves to non public address
+var errForbiddenAddress = errors.New("webhook address is not public")
+
+// Config configures global webhooks, those receive events of all boards, and delivery retries.
+type Config struct {
+	URLs   []string
+	Secret string
+	Events []string // empty means all events
+
+	Backoff      []time.Duration // DefaultBackoff when empty
+	AllowPrivate bool            // allow board webhooks to private network addresses
+}
+
+// job is delivery of event to a webhook
+type job struct {
+	WebhookID uuid.UUID    `json:"webhook_id"`
+	Global    bool         `json:"global,omitempty"`
+	Event     models.Event `json:"event"`
+}
+
+func jobTopic(webhookID uuid.UUID) string {
+	return fmt.Sprintf("webhooks.jobs.%s", webhookID)
+}
+
+// Worker delivers board events to webhooks of the board and global webhooks.
+// Events are taken from a stream of board event topics, then a delivery job is queued for each webhook
+// so webhooks are retried independently.
+type Worker struct {
+	logger *slog.Logger
+	nats   *natsutil.NATS
+	store  *store.Store
+
+	global       []models.Webhook
+	backoff      []time.Duration
+	client       *http.Client // for board webhooks, only public addresses allowed
+	globalClient *http.Client
+}
+
+// NewWorker creates new webhook worker
+func NewWorker(logger *slog.Logger, nats *natsutil.NATS, store *store.Store, config Config) *Worker {
+	var global []models.Webhook
+	for _, u := range config.URLs {
+		global = append(global, models.Webhook{
+			ID:     uuid.NewSHA1(uuid.NameSpaceURL, []byte(u)),
+			URL:    u,
+			Events: config.Events,
+			Secret: config.Secret,
+		})
+	}
+	backoff := config.Backoff
+	if len(backoff) == 0 {
+		backoff = DefaultBackoff
+	}
+	client := newClient(nil)
+	if !config.AllowPrivate {
+		client = newClient(publicOnly)
+	}
+	return &Worker{
+		logger:       logger.With("component", "webhooks"),
+		nats:         nats,
+		store:        store,
+		global:       global,
+		backoff:      backoff,
+		client:       client,
+		globalClient: newClient(nil),
+	}
+}
+
+// newClient returns http client for webhook requests, redirects are not followed.
+// control (when not nil) is called before connecting to the resolved address.
+func newClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicOnly refuses connecting to non public address
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(ap.Addr()) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+// Start processes events and deliveries, it blocks until ctx is done then waits for deliveries in flight.
+func (w *Worker) Start(ctx context.Context) error {
+	stream, err := w.nats.JS.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      streamName,
+		Subjects:  []string{board.EventTopics, jobTopics},
+		Retention: jetstream.WorkQueuePolicy,
+		MaxAge:    streamMaxAge,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhooks stream: %w", err)
+	}
+	dispatch, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       dispatchConsumer,
+		FilterSubject: board.EventTopics,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhooks dispatch consumer: %w", err)
+	}
+	deliver, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:       deliverConsumer,
+		FilterSubject: jobTopics,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       2 * deliveryTimeout,
+		MaxDeliver:    len(w.backoff) + 1,
+		MaxAckPending: 100,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create webhooks deliver consumer: %w", err)
+	}
+
+	dispatching, err := dispatch.Consume(w.dispatch)
+	if err != nil {
+		return err
+	}
+	defer dispatching.Stop()
+
+	// deliveries in flight are finished even when ctx is done, they're bounded by deliveryTimeout
+	var inFlight sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentDeliveries)
+	delivering, err := deliver.Consume(func(msg jetstream.Msg) {
+		sem <- struct{}{}
+		inFlight.Add(1)
+		go func() {
+			defer func() { <-sem; inFlight.Done() }()
+			w.deliver(context.WithoutCancel(ctx), msg)
+		}()
+	})
+	if err != nil {
+		return err
+	}
+	w.logger.Info("webhook worker running...", "global", len(w.global))
+
+	<-ctx.Done()
+	delivering.Stop()
+	inFlight.Wait()
+	w.logger.Info("webhook worker stopped")
+	return nil
+}
+
+// dispatch queues delivery of the event to each webhook subscribed to it
+func (w *Worker) dispatch(msg jetstream.Msg) {
+	ctx := tracing.Extract(context.Background(), &nats.Msg{Header: msg.Headers()})
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	var event models.Event
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		w.logger.Error("failed to decode event", "subject", msg.Subject(), "err", err.Error())
+		msg.Term()
+		return
+	}
+
+	jobs, err := w.jobs(ctx, event)
+	if err != nil {
+		w.logger.Error("failed to get webhooks of event", "board_id", event.BoardID, "err", err.Error())
+		msg.NakWithDelay(w.backoff[0])
+		return
+	}
+	for _, j := range jobs {
+		data, err := json.Marshal(j)
+		if err != nil {
+			w.logger.Error("failed to encode webhook job", "err", err.Error())
+			continue
+		}
+		// message ID deduplicates jobs when the event is dispatched again
+		_, err = w.nats.JS.Publish(ctx, jobTopic(j.WebhookID), data, jetstream.WithMsgID(event.ID.String()+"."+j.WebhookID.String()))
+		if err != nil {
+			metrics.PublishFailures.WithLabelValues("webhook").Inc()
+			w.logger.Error("failed to queue webhook job", "webhook_id", j.WebhookID, "err", err.Error())
+			msg.NakWithDelay(w.backoff[0])
+			return
+		}
+	}
+	msg.Ack()
+}
+
+// jobs returns delivery jobs of the event, to global webhooks and webhooks of its board
+func (w *Worker) jobs(ctx context.Context, event models.Event) ([]job, error) {
+	var jobs []job
+	for _, hook := range w.global {
+		if subscribed(hook, event.Type) {
+			jobs = append(jobs, job{WebhookID: hook.ID, Global: true, Event: event})
+		}
+	}
+	hooks, err := w.store.Webhooks.List(ctx, event.BoardID, MaxPerBoard)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		if subscribed(hook, event.Type) {
+			jobs = append(jobs, job{WebhookID: hook.ID, Event: event})
+		}
+	}
+	return jobs, nil
+}
+
+// webhook returns webhook of the job
+func (w *Worker) webhook(ctx context.Context, j job) (*models.Webhook, error) {
+	if !j.Global {
+		return w.store.Webhooks.Get(ctx, j.Event.BoardID, j.WebhookID)
+	}
+	for _, hook := range w.global {
+		if hook.ID == j.WebhookID {
+			return &hook, nil
+		}
+	}
+	return nil, store.ErrNotFound // removed from config
+}
+
+// deliver sends event of the job to its webhook, failed delivery is retried after backoff.
+// Each attempt is recorded in the webhook delivery log.
+func (w *Worker) deliver(ctx context.Context, msg jetstream.Msg) {
+	var j job
+	if err := json.Unmarshal(msg.Data(), &j); err != nil {
+		w.logger.Error("failed to decode webhook job", "err", err.Error())
+		msg.Term()
+		return
+	}
+	attempt := 1
+	if meta, err := msg.Metadata(); err == nil {
+		attempt = int(meta.NumDelivered)
+	}
+	logger := w.logger.With("webhook_id", j.WebhookID, "event_id", j.Event.ID, "attempt", attempt)
+
+	hook, err := w.webhook(ctx, j)
+	if errors.Is(err, store.ErrNotFound) {
+		msg.Ack() // webhook deleted
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get webhook", "err", err.Error())
+		w.retry(msg, attempt)
+		return
+	}
+
+	start := time.Now()
+	status, err := w.post(ctx, hook, j.Event)
+	delivery := models.WebhookDelivery{
+		ID:         uuid.New(),
+		WebhookID:  hook.ID,
+		EventID:    j.Event.ID,
+		EventType:  j.Event.Type,
+		Attempt:    attempt,
+		StatusCode: status,
+		Duration:   time.Since(start).Milliseconds(),
+		CreatedAt:  start.Unix(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	if err := w.store.Webhooks.AddDelivery(ctx, delivery); err != nil {
+		logger.Error("failed to record webhook delivery", "err", err.Error())
+	}
+
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues("ok").Inc()
+		msg.Ack()
+		return
+	}
+	logger.Warn("webhook delivery failed", "status", status, "err", err.Error())
+	w.retry(msg, attempt)
+}
+
+// retry retries delivery after backoff of the attempt, or gives up when there's no more attempt left
+func (w *Worker) retry(msg jetstream.Msg, attempt int) {
+	if attempt > len(w.backoff) {
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		msg.Term()
+		return
+	}
+	metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+	msg.NakWithDelay(w.backoff[attempt-1])
+}
+
+// post sends signed event to the webhook, it returns response status code
+func (w *Worker) post(ctx context.Context, hook *models.Webhook, event models.Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoRetro-Webhook")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID.String())
+	req.Header.Set(TimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, timestamp, body))
+
+	client := w.client
+	if hook.BoardID == uuid.Nil {
+		client = w.globalClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
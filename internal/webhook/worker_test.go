@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ekaputra07/go-retro/internal/models"
+	"github.com/ekaputra07/go-retro/internal/natsutil"
+	"github.com/ekaputra07/go-retro/internal/store"
+	"github.com/ekaputra07/go-retro/internal/store/natstore"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// receiver is a webhook endpoint that fails the first requests
+type receiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures int
+	received []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, failures int) *receiver {
+	r := &receiver{failures: failures}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, req)
+		r.bodies = append(r.bodies, body)
+		if len(r.received) <= r.failures {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.received)
+}
+
+// testWorker runs worker with embedded NATS server
+func testWorker(t *testing.T, config Config) (*natsutil.NATS, *store.Store) {
+	t.Helper()
+	srv, err := natsutil.RunServer(natsutil.ServerConfig{Name: "test", DataDir: t.TempDir()})
+	require.NoError(t, err)
+	t.Cleanup(srv.Shutdown)
+	nc, err := natsutil.ConnectEmbedded(srv, testLogger)
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+	db, err := natstore.NewStore(context.Background(), nc, "goretro")
+	require.NoError(t, err)
+
+	w := NewWorker(testLogger, nc, db, config)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	// events published before the stream created are not captured
+	require.Eventually(t, func() bool {
+		_, err := nc.JS.Consumer(ctx, streamName, deliverConsumer)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return nc, db
+}
+
+func publish(t *testing.T, nc *natsutil.NATS, event models.Event) {
+	t.Helper()
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	require.NoError(t, nc.Conn.Publish(fmt.Sprintf("events.%s.%s", event.BoardID, event.Type), data))
+}
+
+func TestWorker(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	global := newReceiver(t, 2)
+	boardHook := newReceiver(t, 0)
+	nc, db := testWorker(t, Config{
+		URLs:         []string{global.URL},
+		Secret:       "global-secret",
+		Events:       []string{models.EventBoardCreated},
+		Backoff:      []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		AllowPrivate: true,
+	})
+	ctx := context.Background()
+
+	boardID := uuid.New()
+	hook, err := New(boardID, boardHook.URL, []string{models.EventCardCreated})
+	require.NoError(t, err)
+	require.NoError(t, db.Webhooks.Create(ctx, hook))
+
+	// global webhook is retried until it succeeds
+	created := models.NewEvent(models.EventBoardCreated, boardID, nil)
+	publish(t, nc, created)
+	require.Eventually(t, func() bool { return global.count() == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	global.mu.Lock()
+	for i, req := range global.received {
+		assert.Equal(t, models.EventBoardCreated, req.Header.Get(EventHeader))
+		assert.Equal(t, created.ID.String(), req.Header.Get(DeliveryHeader))
+		ts, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, Sign("global-secret", ts, global.bodies[i]), req.Header.Get(SignatureHeader))
+	}
+	global.mu.Unlock()
+
+	// board webhook only receives events it's subscribed to
+	card := models.NewEvent(models.EventCardCreated, boardID, map[string]any{"name": "ship it"})
+	publish(t, nc, card)
+	require.Eventually(t, func() bool { return boardHook.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	boardHook.mu.Lock()
+	var got models.Event
+	require.NoError(t, json.Unmarshal(boardHook.bodies[0], &got))
+	assert.Equal(t, card.ID, got.ID)
+	ts, _ := strconv.ParseInt(boardHook.received[0].Header.Get(TimestampHeader), 10, 64)
+	assert.Equal(t, Sign(hook.Secret, ts, boardHook.bodies[0]), boardHook.received[0].Header.Get(SignatureHeader))
+	boardHook.mu.Unlock()
+
+	// deliveries are logged, newest first
+	globalID := uuid.NewSHA1(uuid.NameSpaceURL, []byte(global.URL))
+	require.Eventually(t, func() bool {
+		deliveries, err := db.Webhooks.ListDeliveries(ctx, globalID, 10)
+		return err == nil && len(deliveries) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	deliveries, err := db.Webhooks.ListDeliveries(ctx, globalID, 10)
+	require.NoError(t, err)
+	attempts := map[int]models.WebhookDelivery{}
+	for _, d := range deliveries {
+		attempts[d.Attempt] = d
+	}
+	assert.Equal(t, http.StatusInternalServerError, attempts[1].StatusCode)
+	assert.NotEmpty(t, attempts[1].Error)
+	assert.Equal(t, http.StatusOK, attempts[3].StatusCode)
+	assert.Empty(t, attempts[3].Error)
+}
+
+func TestWorkerGivesUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	// board webhooks can't reach private addresses unless allowed
+	private := newReceiver(t, 0)
+	nc, db := testWorker(t, Config{Backoff: []time.Duration{10 * time.Millisecond}})
+	ctx := context.Background()
+
+	boardID := uuid.New()
+	hook, err := New(boardID, private.URL, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Webhooks.Create(ctx, hook))
+
+	publish(t, nc, models.NewEvent(models.EventTimerDone, boardID, nil))
+	require.Eventually(t, func() bool {
+		deliveries, err := db.Webhooks.ListDeliveries(ctx, hook.ID, 10)
+		return err == nil && len(deliveries) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// no more attempts after backoff exhausted
+	time.Sleep(100 * time.Millisecond)
+	deliveries, err := db.Webhooks.ListDeliveries(ctx, hook.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+	for _, d := range deliveries {
+		assert.Contains(t, d.Error, errForbiddenAddress.Error())
+	}
+	assert.Zero(t, private.count())
+}
+
+func TestDeliveriesCapped(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test with embedded NATS server in short mode")
+	}
+	_, db := testWorker(t, Config{})
+	ctx := context.Background()
+
+	// only the latest deliveries are kept, listed newest first
+	hookID := uuid.New()
+	for i := range 105 {
+		require.NoError(t, db.Webhooks.AddDelivery(ctx, models.WebhookDelivery{ID: uuid.New(), WebhookID: hookID, Attempt: 1, CreatedAt: int64(i)}))
+	}
+	deliveries, err := db.Webhooks.ListDeliveries(ctx, hookID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 10)
+	for i, d := range deliveries {
+		assert.Equal(t, int64(104-i), d.CreatedAt)
+	}
+	deliveries, err = db.Webhooks.ListDeliveries(ctx, hookID, 1000)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 100)
+	assert.Equal(t, int64(5), deliveries[len(deliveries)-1].CreatedAt)
+}
@@ -1,10 +1,13 @@
-import { useState, useMemo, Activity } from 'react'
+import { useState, useCallback, useRef, Activity } from 'react'
 import useWebSocket from 'react-use-websocket'
 import { DndProvider } from 'react-dnd'
 import { HTML5Backend } from 'react-dnd-html5-backend'
 import Alert from './components/Alert'
 import Timer from './components/Timer'
 import NameModal from './components/NameModal'
+import PasscodeModal from './components/PasscodeModal'
+import { AccessModal } from './components/AccessModal'
+import { ProfileModal } from './components/ProfileModal'
 import { TimerModal, useTimerModal } from './components/TimerModal'
 import { ColumnModal, useColumnModal } from './components/ColumnModal'
 import Toolbar from './components/Toolbar'
@@ -21,6 +24,12 @@ declare global {
       AppName: string
       AppVersion: string
       AppTagline: string
+      LoginEnabled?: boolean
+      User?: User | null
+      AccessRequired?: boolean
+      WSTicket?: string
+      IssueTracker?: string
+      Spectator?: boolean
     };
   }
 }
@@ -43,47 +52,91 @@ const gridColsClass = (length: number): string => {
 }
 
 // Build WebSocket URL helper
-const buildWebSocketUrl = (userName: string): string => {
+const buildWebSocketUrl = (userName: string, ticket: string): string => {
   const host = import.meta.env.DEV ? 'localhost:8080' : window.location.host
   const protocol = window.location.protocol
   const pathname = window.location.pathname
   const wsProtocol = protocol === 'https:' ? 'wss:' : 'ws:'
-  return `${wsProtocol}//${host}${pathname}/ws?u=${userName}`
+  return `${wsProtocol}//${host}${pathname}/ws?u=${encodeURIComponent(userName)}&t=${encodeURIComponent(ticket)}`
 }
 
+// websocket ticket given by the board page is short-lived and used only for the first connection,
+// new one is requested on every reconnect
+let pageTicket = window.GORETRO_DATA?.WSTicket || ''
+const pageTicketExpiresAt = Date.now() + 60 * 1000
+
+const getWebSocketTicket = async (): Promise<string> => {
+  if (pageTicket !== '' && Date.now() < pageTicketExpiresAt) {
+    const ticket = pageTicket
+    pageTicket = ''
+    return ticket
+  }
+  const resp = await fetch(window.location.pathname + '/ws/ticket')
+  if (!resp.ok) return ''
+  const data: { ticket: string } = await resp.json()
+  return data.ticket
+}
+
+const loginEnabled = window.GORETRO_DATA?.LoginEnabled || false
+const currentUser = window.GORETRO_DATA?.User || null
+const accessRequired = window.GORETRO_DATA?.AccessRequired || false
+const issueTracker = window.GORETRO_DATA?.IssueTracker || ''
+const spectator = window.GORETRO_DATA?.Spectator || false
+
+// close code of the websocket when the board is deleted
+const closeBoardDeleted = 4004
+
 function App() {
   const nameKey = 'GR_USERNAME'
 
-  // Lazy initialization: read from localStorage only on first render
+  // Lazy initialization: name is kept by the server, localStorage is used when the user is new
+  // (e.g previous one expired). Signed-in user's name comes from SSO provider.
   const [name, setName] = useState<string>(() => {
-    return localStorage.getItem(nameKey) || ''
+    return currentUser?.name || localStorage.getItem(nameKey) || ''
   })
+  // name is read on every (re)connect, so it's never reverted by the name used on first connect
+  const nameRef = useRef(name)
+  const [profileUser, setProfileUser] = useState<User | null>(null)
+  const [boardDeleted, setBoardDeleted] = useState(false)
 
-  // Compute WebSocket URL and connection state from name
-  const socketUrl = useMemo(() => {
-    return name ? buildWebSocketUrl(name) : ''
-  }, [name])
+  // Connection state from name, private board can't be joined until passcode accepted
+  const canConnect = name !== '' && !accessRequired
+  const getSocketUrl = useCallback(async () => {
+    return buildWebSocketUrl(nameRef.current, await getWebSocketTicket())
+  }, [])
 
   // webhook connection
-  const { lastMessage, sendJsonMessage } = useWebSocket(socketUrl, {
+  const { lastMessage, sendJsonMessage } = useWebSocket(getSocketUrl, {
     onOpen: () => {
       console.log('WebSocket connection opened.')
       sendJsonMessage({ type: 'me' })
     },
-    onClose: () => console.log('WebSocket connection closed.'),
+    onClose: (event) => {
+      console.log('WebSocket connection closed.')
+      if (event.code === closeBoardDeleted) setBoardDeleted(true)
+    },
     onError: (event) => console.error('WebSocket error observed:', event),
-    shouldReconnect: () => true,
-  }, socketUrl !== '')
+    shouldReconnect: (event) => event.code !== closeBoardDeleted,
+  }, canConnect && !boardDeleted)
 
   // board state
   const [notification, setNotification] = useNotification(2000)
-  const { users, userConnectionsCount, columns, cards, timerRunning, timerState } = useBoardState(lastMessage, setNotification)
+  const { board, users, spectators, userConnectionsCount, columns, cards, timerRunning, timerState } = useBoardState(lastMessage, setNotification)
   const [standupOpen, standupSetOpen, standupProps] = useStandup(users, setNotification)
   const [timerModalOpen, timerModalSetOpen, timerModalProps] = useTimerModal(sendJsonMessage)
   const [columnModalOpen, columnModalSetOpen, columnModalProps] = useColumnModal(sendJsonMessage)
+  const [accessModalOpen, setAccessModalOpen] = useState(false)
+
+  // nobody changes locked or archived board, spectators never do
+  const readOnly = spectator || !!board?.locked || !!board?.archived
+
+  // only facilitator changes board settings, and creates issues of cards when issue tracker is configured
+  const facilitator = !!board && !!currentUser && board.facilitator_id === currentUser.id
+  const cardIssueTracker = facilitator ? issueTracker : ''
 
   const saveName = (name: string): void => {
     localStorage.setItem(nameKey, name)
+    nameRef.current = name
     setName(name)
   }
 
@@ -95,7 +148,14 @@ function App() {
           {timerRunning && timerState && <Timer state={timerState} sender={sendJsonMessage} />}
 
           {/* I put a 100ms delay in NameModal so that it won't create a short blip */}
-          {socketUrl === '' && <NameModal onJoin={saveName} />}
+          {accessRequired && <PasscodeModal />}
+          {name === '' && !accessRequired && <NameModal onJoin={saveName} loginEnabled={loginEnabled} />}
+          {boardDeleted &&
+            <div className="py-16 text-center text-gray-700">
+              <p className="mb-4">This board has been deleted by its facilitator.</p>
+              <a href="/" className="text-sky-600 font-medium underline">Start a new board</a>
+            </div>
+          }
 
           <div className="py-4 px-6">
             {/* kanban board */}
@@ -109,10 +169,10 @@ function App() {
               {/* columns */}
               <div className={"flex-1 grid gap-4 pb-2 items-start " + gridColsClass(columns.length)}>
                 {columns.map((col) =>
-                  <ColumnItem column={col} sender={sendJsonMessage} key={col.id}>
+                  <ColumnItem column={col} sender={sendJsonMessage} readOnly={readOnly} key={col.id}>
                     {cards
                       .filter(c => c.column_id === col.id)
-                      .map((c) => <CardItem column={col} card={c} sender={sendJsonMessage} key={c.id} />)}
+                      .map((c) => <CardItem column={col} card={c} sender={sendJsonMessage} issueTracker={cardIssueTracker} readOnly={readOnly} key={c.id} />)}
                   </ColumnItem>
                 )}
               </div>
@@ -122,16 +182,32 @@ function App() {
 
         <div className="flex flex-col">
           <Activity mode={timerModalOpen ? 'visible' : 'hidden'}>
-            <TimerModal {...timerModalProps} />
+            <TimerModal {...timerModalProps} board={board} canChangeSettings={facilitator} />
           </Activity>
 
           {columnModalOpen && <ColumnModal {...columnModalProps} />}
+          {accessModalOpen && board && <AccessModal board={board} sender={sendJsonMessage} onClose={() => setAccessModalOpen(false)} />}
+          {profileUser &&
+            <ProfileModal
+              user={profileUser}
+              onCancel={() => setProfileUser(null)}
+              onSave={(u: User) => { saveName(u.name); setProfileUser(null) }}
+            />
+          }
           <Toolbar
             users={users}
             conn={userConnectionsCount}
-            showStandupBtn={!standupOpen}
-            showTimerBtn={!timerRunning}
-            onAvatarClick={(u: User) => setNotification(u.name)}
+            showStandupBtn={!standupOpen && !spectator}
+            showTimerBtn={!timerRunning && !readOnly}
+            showColumnBtn={!readOnly}
+            onAvatarClick={(u: User) => {
+              // own profile can be changed, unless it comes from SSO provider
+              if (u.id === currentUser?.id && !u.authenticated) {
+                setProfileUser(u)
+              } else {
+                setNotification(u.name)
+              }
+            }}
             onNewColumn={() => {
               if (columns.length >= 6) {
                 setNotification("Can only create maximum 6 columns!")
@@ -144,7 +220,14 @@ function App() {
           />
           <Footer
             userCount={users.length}
+            spectatorCount={spectators.length}
+            spectator={spectator}
             appInfo={appInfo}
+            user={currentUser}
+            loginEnabled={loginEnabled}
+            board={board}
+            onRequireAuth={(required: boolean) => sendJsonMessage({ type: 'board.update', data: { require_auth: required } })}
+            onAccessSettings={() => setAccessModalOpen(true)}
           />
         </div>
       </div>
@@ -0,0 +1,11 @@
+// boardPath is the path of current board page e.g /b/<id>
+export const boardPath = window.location.pathname
+
+// requestJSON sends JSON request to the server, the server only accepts JSON body for these endpoints
+export async function requestJSON(method: string, path: string, body?: object): Promise<Response> {
+    return fetch(path, {
+        method,
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body || {}),
+    })
+}
@@ -0,0 +1,9 @@
+import type { User } from './types'
+
+// avatarSrc returns avatar image of the user: uploaded or SSO avatar, bundled avatar,
+// or the one generated from user ID (avatar_id 0)
+export function avatarSrc(u: User): string {
+    if (u.avatar_url) return u.avatar_url
+    if (u.avatar_id) return import.meta.env.BASE_URL + 'avatar/' + u.avatar_id + '.png'
+    return '/avatars/' + u.id
+}
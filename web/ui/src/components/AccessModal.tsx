@@ -0,0 +1,100 @@
+import { useState } from 'react'
+import type { Board } from '../types'
+import { boardPath, requestJSON } from '../api'
+import { Webhooks } from './Webhooks'
+
+interface props {
+    board: Board
+    sender: (data: object) => void
+    onClose(): void
+}
+
+export function AccessModal(p: props) {
+    const [priv, setPriv] = useState(p.board.private)
+    const [passcode, setPasscode] = useState('')
+    const [inviteURL, setInviteURL] = useState('')
+    const [message, setMessage] = useState('')
+
+    const save = async () => {
+        // passcode only changed when filled, changing it revokes access granted with the old one
+        const data: { private: boolean, passcode?: string } = { private: priv }
+        if (passcode !== '') data.passcode = passcode
+        const resp = await requestJSON('POST', boardPath + '/settings/access', data)
+        if (resp.ok) {
+            p.onClose()
+        } else {
+            setMessage(await resp.text())
+        }
+    }
+    // view link lets people watch the board without changing it, also on public board
+    const createInvite = async (viewOnly: boolean) => {
+        const resp = await requestJSON('POST', boardPath + '/invites', { view_only: viewOnly })
+        if (resp.ok) {
+            const invite: { url: string } = await resp.json()
+            setInviteURL(window.location.origin + invite.url)
+        } else {
+            setMessage(await resp.text())
+        }
+    }
+    const revokeInvites = async () => {
+        const resp = await requestJSON('DELETE', boardPath + '/invites')
+        if (resp.ok) {
+            setInviteURL('')
+            setMessage('All invites and view links revoked, others need a new invite or the passcode to join.')
+        } else {
+            setMessage(await resp.text())
+        }
+    }
+
+    // deleted board closes everyone's connection, including ours
+    const deleteBoard = () => {
+        if (window.confirm('Delete this board with all its columns and cards? This can\'t be undone.')) {
+            p.sender({ type: 'board.delete' })
+        }
+    }
+
+    return (
+        <div className="fixed inset-0 flex h-screen w-full items-end md:items-center justify-center z-10">
+            <div className="absolute inset-0 bg-black opacity-50"></div>
+            <div className="md:p-4 md:max-w-lg mx-auto w-full flex-1 relative overflow-hidden">
+                <div className="w-full rounded-t-lg md:rounded-md bg-white p-8">
+                    <h2 className="font-semibold text-xl mb-6 text-gray-800">Board Access</h2>
+                    <form onSubmit={(e) => { e.preventDefault(); save() }}>
+                        <label className="flex items-center gap-2 mb-4 text-gray-700">
+                            <input onChange={e => setPriv(e.target.checked)} checked={priv} type="checkbox" />
+                            Private, only people with passcode or invite link can join
+                        </label>
+                        <div className="mb-4">
+                            <input
+                                value={passcode}
+                                onChange={(e) => setPasscode(e.target.value)}
+                                placeholder="New passcode (leave empty to keep current)"
+                                minLength={8}
+                                maxLength={72}
+                                type="password" className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
+                        </div>
+                        <div className="mb-4 text-sm">
+                            <input type="button" value="Create invite link" onClick={() => createInvite(false)} className="text-sky-600 font-medium cursor-pointer mr-4" />
+                            <input type="button" value="Create view link" onClick={() => createInvite(true)} className="text-sky-600 font-medium cursor-pointer mr-4" />
+                            <input type="button" value="Revoke all invites" onClick={revokeInvites} className="text-red-600 font-medium cursor-pointer" />
+                            {inviteURL && <input readOnly value={inviteURL} onFocus={e => e.target.select()} type="text" className="mt-2 bg-gray-100 border border-gray-200 rounded-md w-full py-1 px-2 text-gray-700" />}
+                            {message && <p className="text-gray-500 mt-2">{message}</p>}
+                        </div>
+                        <div className="mb-4 text-sm">
+                            <input type="button" value={p.board.locked ? 'Unlock board' : 'Lock board'} onClick={() => p.sender({ type: 'board.lock', data: { locked: !p.board.locked } })} className="text-sky-600 font-medium cursor-pointer mr-4" />
+                            <input type="button" value={p.board.archived ? 'Unarchive board' : 'Archive board'} onClick={() => p.sender({ type: 'board.archive', data: { archived: !p.board.archived } })} className="text-sky-600 font-medium cursor-pointer mr-4" />
+                            <input type="button" value="Delete board" onClick={deleteBoard} className="text-red-600 font-medium cursor-pointer" />
+                        </div>
+                        <Webhooks />
+                        <div className="flex justify-between items-center mt-8 text-right">
+                            <div className="flex-1">
+                                <input type="button" value="Close" onClick={p.onClose} className="bg-white hover:bg-gray-100 text-gray-700 font-semibold py-1 px-4 border border-gray-300 rounded-md shadow-sm mr-2" />
+                                <input value="Save" type="submit" className="text-white font-semibold py-1 px-4 border border-transparent rounded-md shadow-sm bg-sky-600 hover:bg-sky-700" />
+                            </div>
+                        </div>
+                    </form>
+                </div>
+            </div>
+        </div>
+    )
+}
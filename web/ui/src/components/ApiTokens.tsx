@@ -0,0 +1,63 @@
+import { useState, useEffect } from 'react'
+import { requestJSON } from '../api'
+
+interface apiToken {
+    id: string
+    name: string
+    expires_at: number
+}
+
+// ApiTokens lists API tokens of the user, new token is only shown once after it's created
+export function ApiTokens() {
+    const [tokens, setTokens] = useState<apiToken[]>([])
+    const [name, setName] = useState('')
+    const [created, setCreated] = useState('')
+    const [error, setError] = useState('')
+
+    const load = async () => {
+        const resp = await fetch('/profile/tokens')
+        if (resp.ok) setTokens(await resp.json())
+    }
+    useEffect(() => { load() }, [])
+
+    const create = async () => {
+        const resp = await requestJSON('POST', '/profile/tokens', { name })
+        if (resp.ok) {
+            const token: { token: string } = await resp.json()
+            setCreated(token.token)
+            setName('')
+            setError('')
+            load()
+        } else {
+            setError(await resp.text())
+        }
+    }
+    const remove = async (id: string) => {
+        const resp = await requestJSON('DELETE', '/profile/tokens/' + id)
+        if (resp.ok) load()
+    }
+
+    return (
+        <div className="mb-4 text-sm">
+            <h3 className="font-semibold text-gray-700 mb-2">API tokens</h3>
+            {tokens.map(t => (
+                <div key={t.id} className="flex justify-between text-gray-600 mb-1">
+                    <span>{t.name} <span className="text-gray-400">expires {new Date(t.expires_at * 1000).toLocaleDateString()}</span></span>
+                    <input type="button" value="Revoke" onClick={() => remove(t.id)} className="text-red-600 cursor-pointer" />
+                </div>
+            ))}
+            <div className="flex gap-2 mt-2">
+                <input
+                    value={name}
+                    onChange={(e) => setName(e.target.value)}
+                    placeholder="Token name"
+                    maxLength={64}
+                    type="text" className="flex-1 bg-gray-200 border-2 border-gray-200 rounded-md py-1 px-2 text-gray-700 focus:outline-none focus:bg-white focus:border-sky-500" />
+                <input type="button" value="Create" disabled={!name.trim()} onClick={create} className="text-sky-600 font-medium cursor-pointer" />
+            </div>
+            {created && <input readOnly value={created} onFocus={e => e.target.select()} type="text" className="mt-2 bg-gray-100 border border-gray-200 rounded-md w-full py-1 px-2 text-gray-700" />}
+            {created && <p className="text-gray-500 mt-1">Copy the token now, it won't be shown again.</p>}
+            {error && <p className="text-red-600 mt-1">{error}</p>}
+        </div>
+    )
+}
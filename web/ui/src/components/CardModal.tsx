@@ -1,10 +1,12 @@
 import { useState, useRef, useEffect } from 'react'
 import { EmojiButton } from "@joeattardi/emoji-button"
 import type { Column, Card } from '../types'
+import { boardPath, requestJSON } from '../api'
 
 interface props {
     column: Column
     card: Card
+    issueTracker: string
     onCancel(): void
     onDelete(col: Card): void
     onSave(col: Card): void
@@ -21,6 +23,23 @@ export function CardModal(p: props) {
     const inputRef = useRef<HTMLInputElement>(null)
     const emojiBtnRef = useRef<HTMLButtonElement>(null)
     const [name, setName] = useState(p.card.name)
+    const [issueURL, setIssueURL] = useState(p.card.issue_url || '')
+    const [issueError, setIssueError] = useState('')
+    const [creatingIssue, setCreatingIssue] = useState(false)
+
+    // issue URL is stored on the card by the server, everyone sees it on the board
+    const createIssue = async () => {
+        setCreatingIssue(true)
+        const resp = await requestJSON('POST', boardPath + '/cards/' + p.card.id + '/issue')
+        if (resp.ok) {
+            const card: Card = await resp.json()
+            setIssueURL(card.issue_url || '')
+            setIssueError('')
+        } else {
+            setIssueError(await resp.text())
+        }
+        setCreatingIssue(false)
+    }
 
     const openEmojiPicker = (): void => {
         emojiPicker.showPicker(emojiBtnRef.current as HTMLElement)
@@ -60,6 +79,14 @@ export function CardModal(p: props) {
                                 type="text" className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 pr-8 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
                         </div>
 
+                        {p.card.id && p.issueTracker && !issueURL &&
+                            <div className="text-sm">
+                                <input type="button" value={creatingIssue ? 'Creating issue...' : 'Create ' + p.issueTracker + ' issue'} disabled={creatingIssue} onClick={createIssue} className="text-sky-600 font-medium cursor-pointer disabled:text-gray-400" />
+                                {issueError && <div className="text-xs text-red-500 mt-1">{issueError}</div>}
+                            </div>
+                        }
+                        {issueURL && <a href={issueURL} target="_blank" rel="noopener noreferrer" className="text-sm text-sky-600 hover:underline">{issueURL}</a>}
+
                         <div className="flex justify-between items-center mt-8 text-right">
                             {p.card.id && <input type="button" value="Delete" onClick={() => p.onDelete(p.card)} className="text-sm text-red-600 font-medium cursor-pointer" />}
                             <div className="flex-1">
@@ -74,7 +101,7 @@ export function CardModal(p: props) {
     )
 }
 
-export function useCardModal(sender: (data: object) => void): [boolean, (col: Column, card: Card | null) => void, props] {
+export function useCardModal(sender: (data: object) => void, issueTracker = ''): [boolean, (col: Column, card: Card | null) => void, props] {
     const [isOpen, setIsOpen] = useState(false)
     const [column, setColumn] = useState<Column>({ name: '' })
     const [card, setCard] = useState<Card>({ name: '' })
@@ -115,6 +142,6 @@ export function useCardModal(sender: (data: object) => void): [boolean, (col: Co
     return [
         isOpen,
         setOpen,
-        { column, card, onCancel, onSave, onDelete }
+        { column, card, issueTracker, onCancel, onSave, onDelete }
     ]
 }
\ No newline at end of file
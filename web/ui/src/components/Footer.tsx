@@ -1,8 +1,16 @@
-import type { AppInfo } from '../types'
+import type { AppInfo, Board, User } from '../types'
+import { boardPath } from '../api'
 
 interface props {
     userCount: number
+    spectatorCount: number
+    spectator: boolean // current user only watches the board
     appInfo: AppInfo
+    user?: User | null
+    loginEnabled: boolean
+    board?: Board | null
+    onRequireAuth(required: boolean): void
+    onAccessSettings(): void
 }
 
 const usersOnlineText = (count: number): string => {
@@ -20,7 +28,31 @@ export default function Footer(p: props) {
                 <div className="flex items-center">
                     <span className="flex w-2 h-2 me-1 bg-green-500 rounded-full"></span> 
                     <span>{usersOnlineText(p.userCount)}</span>
+                    {p.spectatorCount > 0 && <span>, {p.spectatorCount} watching</span>}
                 </div>
+                {p.spectator && <span className="font-semibold">You're watching, the board is read-only</span>}
+                {!p.spectator && p.board?.locked && <span className="font-semibold">Board is locked, it's read-only</span>}
+                {!p.spectator && !p.board?.locked && p.board?.archived && <span className="font-semibold">Board is archived, it's read-only</span>}
+                <a href={boardPath + '/summary'} className="underline" target="_blank">Summary</a>
+                {p.board && p.user && p.board.facilitator_id === p.user.id &&
+                    <button onClick={p.onAccessSettings} className="underline cursor-pointer">{p.board.private ? 'Private board' : 'Make private'}</button>
+                }
+                {p.user?.authenticated &&
+                    <>
+                        {p.board && p.board.facilitator_id === p.user.id &&
+                            <label className="flex items-center gap-1">
+                                <input onChange={e => p.onRequireAuth(e.target.checked)} checked={p.board.require_auth} type="checkbox" />
+                                Only signed-in users can join
+                            </label>
+                        }
+                        <form method="post" action={'/auth/logout?next=' + encodeURIComponent(window.location.pathname)}>
+                            <span>Signed in as {p.user.name}</span> <input type="submit" value="Sign out" className="underline cursor-pointer" />
+                        </form>
+                    </>
+                }
+                {!p.user?.authenticated && p.loginEnabled &&
+                    <a href={'/auth/login?next=' + encodeURIComponent(window.location.pathname)} className="underline">Sign in</a>
+                }
             </div>
             <p className="text-xs text-gray-600 text-center hidden md:block">
                 <a href="https://github.com/ekaputra07/go-retro" className="underline" target="_blank">{p.appInfo.name} ({p.appInfo.version})</a> - {p.appInfo.tagline}
@@ -1,6 +1,7 @@
 import { useState, useRef, useEffect } from 'react'
 
 interface props {
+    loginEnabled: boolean
     onJoin(name: string): void
 }
 
@@ -35,10 +36,14 @@ export default function NameModal(p: props) {
                                 value={name}
                                 ref={inputRef}
                                 required
+                                maxLength={32}
                                 type="text" className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
                             <p className="text-gray-500 text-sm mt-2">Name used to show who's joining, cards are anonymous.</p>
                         </div>
                         <div className="flex justify-between items-center mt-8 text-right">
+                            {p.loginEnabled &&
+                                <a href={'/auth/login?next=' + encodeURIComponent(window.location.pathname)} className="text-sky-600 text-sm">Sign in with SSO</a>
+                            }
                             <div className="flex-1">
                                 <input type="submit" value="Join" className="text-white font-semibold py-1 px-4 border border-transparent rounded-md shadow-sm bg-sky-600 hover:bg-sky-700" />
                             </div>
@@ -0,0 +1,54 @@
+import { useState, useRef, useEffect } from 'react'
+import { boardPath, requestJSON } from '../api'
+
+export default function PasscodeModal() {
+    const inputRef = useRef<HTMLInputElement>(null)
+    const [passcode, setPasscode] = useState('')
+    const [error, setError] = useState('')
+
+    useEffect(() => {
+        if (inputRef.current) {
+            inputRef.current.focus()
+        }
+    }, [])
+
+    const submit = async () => {
+        const resp = await requestJSON('POST', boardPath + '/access', { passcode })
+        if (resp.ok) {
+            window.location.reload()
+        } else if (resp.status === 429) {
+            setError('Too many attempts, please try again later.')
+        } else {
+            setError('Wrong passcode, please try again.')
+        }
+    }
+
+    return (
+        <div className="fixed inset-0 flex h-screen w-full items-end md:items-center justify-center z-10">
+            <div className="absolute inset-0 bg-black opacity-50"></div>
+            <div className="md:p-4 md:max-w-lg mx-auto w-full flex-1 relative overflow-hidden">
+                <form onSubmit={e => { e.preventDefault(); submit() }}>
+                    <div className="w-full rounded-t-lg md:rounded-md bg-white p-8">
+                        <h2 className="font-semibold text-xl mb-6 text-gray-800">Private board</h2>
+                        <div className="mb-4">
+                            <input
+                                onChange={e => setPasscode(e.target.value)}
+                                value={passcode}
+                                ref={inputRef}
+                                required
+                                placeholder="Passcode"
+                                type="password" className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
+                            <p className="text-gray-500 text-sm mt-2">Enter the board passcode, or ask the facilitator for an invite link.</p>
+                            {error && <p className="text-red-600 text-sm mt-2">{error}</p>}
+                        </div>
+                        <div className="flex justify-between items-center mt-8 text-right">
+                            <div className="flex-1">
+                                <input type="submit" value="Join" className="text-white font-semibold py-1 px-4 border border-transparent rounded-md shadow-sm bg-sky-600 hover:bg-sky-700" />
+                            </div>
+                        </div>
+                    </div>
+                </form>
+            </div>
+        </div>
+    )
+}
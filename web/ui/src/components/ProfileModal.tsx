@@ -0,0 +1,104 @@
+import { useState, useRef, useEffect } from 'react'
+import type { User } from '../types'
+import { requestJSON } from '../api'
+import { avatarSrc } from '../avatar'
+import { ApiTokens } from './ApiTokens'
+
+// number of avatars in public/avatar
+const avatarsCount = 12
+
+interface props {
+    user: User
+    onCancel(): void
+    onSave(user: User): void
+}
+
+export function ProfileModal(p: props) {
+    const inputRef = useRef<HTMLInputElement>(null)
+    const [name, setName] = useState(p.user.name)
+    // avatar only sent when changed, choosing one removes uploaded avatar
+    const [avatarId, setAvatarId] = useState<number | null>(null)
+    const [error, setError] = useState('')
+
+    useEffect(() => {
+        if (inputRef.current) {
+            inputRef.current.focus()
+        }
+    }, [])
+
+    const save = async () => {
+        const data: { name: string, avatar_id?: number } = { name }
+        if (avatarId !== null) data.avatar_id = avatarId
+        const resp = await requestJSON('POST', '/profile', data)
+        if (resp.ok) {
+            p.onSave(await resp.json())
+        } else {
+            setError(await resp.text())
+        }
+    }
+    // uploaded image is resized by the server
+    const upload = async (file: File) => {
+        const resp = await fetch('/profile/avatar', {
+            method: 'POST',
+            headers: { 'Content-Type': file.type },
+            body: file,
+        })
+        if (resp.ok) {
+            p.onSave(await resp.json())
+        } else {
+            setError(await resp.text())
+        }
+    }
+    const selected = (id: number): boolean => {
+        if (avatarId !== null) return id === avatarId
+        return !p.user.avatar_url && id === p.user.avatar_id
+    }
+
+    return (
+        <div className="fixed inset-0 flex h-screen w-full items-end md:items-center justify-center z-10">
+            <div className="absolute inset-0 bg-black opacity-50"></div>
+            <div className="md:p-4 md:max-w-lg mx-auto w-full flex-1 relative overflow-hidden">
+                <div className="w-full rounded-t-lg md:rounded-md bg-white p-8">
+                    <h2 className="font-semibold text-xl mb-6 text-gray-800">Your Profile</h2>
+                    <form onSubmit={(e) => { e.preventDefault(); save() }}>
+                        <div className="mb-4">
+                            <input
+                                ref={inputRef}
+                                value={name}
+                                onChange={(e) => setName(e.target.value)}
+                                placeholder="Your name"
+                                required
+                                maxLength={32}
+                                type="text" className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
+                        </div>
+                        <div className="grid grid-cols-6 gap-2 mb-4">
+                            {p.user.avatar_url &&
+                                <img src={p.user.avatar_url} alt="avatar" className="w-12 h-12 rounded-full border-2 border-sky-600" />
+                            }
+                            {Array.from({ length: avatarsCount + 1 }, (_, i) => i).map(id => (
+                                <img
+                                    key={id}
+                                    src={avatarSrc({ ...p.user, avatar_url: undefined, avatar_id: id })}
+                                    alt="avatar"
+                                    onClick={() => setAvatarId(id)}
+                                    className={"w-12 h-12 rounded-full cursor-pointer border-2 " + (selected(id) ? 'border-sky-600' : 'border-white')} />
+                            ))}
+                        </div>
+                        <label className="block text-sm text-sky-600 cursor-pointer mb-4">
+                            Upload image
+                            <input type="file" accept="image/png,image/jpeg,image/gif" className="hidden" onChange={e => e.target.files?.[0] && upload(e.target.files[0])} />
+                        </label>
+                        <ApiTokens />
+                        {error && <p className="text-red-600 text-sm mb-4">{error}</p>}
+                        <div className="flex justify-between items-center mt-8 text-right">
+                            <div className="flex-1">
+                                <input type="button" value="Cancel" onClick={p.onCancel} className="bg-white hover:bg-gray-100 text-gray-700 font-semibold py-1 px-4 border border-gray-300 rounded-md shadow-sm mr-2" />
+                                <input value="Save" type="submit" className="text-white font-semibold py-1 px-4 border border-transparent rounded-md shadow-sm bg-sky-600 hover:bg-sky-700" />
+                            </div>
+                        </div>
+                    </form>
+                </div>
+            </div>
+        </div>
+    )
+}
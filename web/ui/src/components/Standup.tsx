@@ -1,5 +1,6 @@
 import { useEffect, useState } from 'react'
 import type { User } from '../types'
+import { avatarSrc } from '../avatar'
 
 interface props {
     users: User[]
@@ -61,7 +62,7 @@ export function Standup(p: props) {
             <div className="py-2">
                 {currentUsers.map((u) => (
                     <div key={u.id} title={u.name} onClick={() => setUser(u)} className={'flex justify-between items-center cursor-pointer px-4 py-2 hover:bg-green-50' + ((u.id == user?.id) ? ' bg-green-100' : '')}>
-                        <img src={import.meta.env.BASE_URL + 'avatar/' + u.avatar_id + '.png'} alt="avatar" className="w-6 h-6 rounded-full border-2 border-white shadow-sm mr-2 cursor-pointer" />
+                        <img src={avatarSrc(u)} alt="avatar" className="w-6 h-6 rounded-full border-2 border-white shadow-sm mr-2 cursor-pointer" />
                         <span className="flex-1 font-bold text-sm text-gray-700 cursor-pointer">{u.name}</span>
                         {(u.id == user?.id) &&
                             <span className="relative flex size-3 mr-1">
@@ -1,11 +1,39 @@
+import { useEffect, useState } from 'react'
 import type { TimerState } from '../types'
 
+// remainingMs returns remaining time of the timer, running timer counts down locally from its end time
+function remainingMs(s: TimerState, now: number): number {
+    if (s.status === 'running') {
+        return Math.max(s.ends_at - (now + (s.offset || 0)), 0)
+    }
+    return s.remaining
+}
+
+// formatDuration formats milliseconds as mm:ss or h:mm:ss
+export function formatDuration(ms: number): string {
+    const total = Math.ceil(ms / 1000)
+    const h = Math.floor(total / 3600)
+    const m = Math.floor((total % 3600) / 60)
+    const s = total % 60
+    const pad = (n: number): string => n.toString().padStart(2, '0')
+    return h > 0 ? `${h}:${pad(m)}:${pad(s)}` : `${pad(m)}:${pad(s)}`
+}
+
 interface props {
     state: TimerState
     sender: (msg: object) => void
 }
 
 export default function Timer(p: props) {
+    const [now, setNow] = useState<number>(Date.now())
+
+    // re-render periodically only while running, state changes are pushed by server
+    useEffect(() => {
+        if (p.state.status !== 'running') return
+        const id = setInterval(() => setNow(Date.now()), 250)
+        return () => clearInterval(id)
+    }, [p.state.status])
+
     const pause = (): void => {
         p.sender({
             type: 'timer.cmd',
@@ -18,6 +46,18 @@ export default function Timer(p: props) {
             data: { cmd: 'start' }
         })
     }
+    const add = (value: string): void => {
+        p.sender({
+            type: 'timer.cmd',
+            data: { cmd: 'add', value }
+        })
+    }
+    const subtract = (value: string): void => {
+        p.sender({
+            type: 'timer.cmd',
+            data: { cmd: 'subtract', value }
+        })
+    }
     const stop = (): void => {
         p.sender({
             type: 'timer.cmd',
@@ -35,7 +75,8 @@ export default function Timer(p: props) {
 
                 {(p.state.status != "done" && p.state.status != "stopped") &&
                     <div className="flex items-center gap-2">
-                        <div className="text-xl font-bold leading-none">{p.state.display}</div>
+                        <button type="button" onClick={() => subtract('1m')} title="Subtract 1 minute" className="cursor-pointer text-sm font-semibold">-1m</button>
+                        <div className="text-xl font-bold leading-none">{formatDuration(remainingMs(p.state, now))}</div>
 
                         {p.state.status == "running" &&
                             <button type="button" onClick={pause} title="Pause" className="cursor-pointer">
@@ -48,6 +89,7 @@ export default function Timer(p: props) {
                                 <svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><path fill="currentColor" d="M12 2a10 10 0 1 0 10 10A10 10 0 0 0 12 2m-2 14.5v-9l6 4.5z" /></svg>
                             </button>
                         }
+                        <button type="button" onClick={() => add('1m')} title="Add 1 minute" className="cursor-pointer text-sm font-semibold">+1m</button>
                     </div>
                 }
 
@@ -1,13 +1,46 @@
 import { useState, useRef, useEffect } from 'react'
+import type { Board } from '../types'
+
+export interface TimerSettings {
+    timer_presets: string[]
+    timer_warning: number
+    timer_auto_advance: boolean
+}
 
 interface props {
+    board?: Board | null
+    canChangeSettings?: boolean // only facilitator changes board timer settings
     onCancel(): void
     onStart(duration: string): void
+    onSaveSettings(settings: TimerSettings): void
 }
 
 export function TimerModal(p: props) {
     const inputRef = useRef<HTMLInputElement>(null)
     const [duration, setDuration] = useState('5m')
+    const [settingsOpen, setSettingsOpen] = useState(false)
+    const [presets, setPresets] = useState('')
+    const [warning, setWarning] = useState(0)
+    const [autoAdvance, setAutoAdvance] = useState(false)
+
+    const boardPresets = p.board?.timer_presets || []
+
+    // reset settings form whenever board settings changed
+    useEffect(() => {
+        if (!p.board) return
+        setPresets((p.board.timer_presets || []).join(', '))
+        setWarning(p.board.timer_warning)
+        setAutoAdvance(p.board.timer_auto_advance)
+    }, [p.board])
+
+    const saveSettings = (): void => {
+        p.onSaveSettings({
+            timer_presets: presets.split(',').map(s => s.trim()).filter(s => s !== ''),
+            timer_warning: warning,
+            timer_auto_advance: autoAdvance,
+        })
+        setSettingsOpen(false)
+    }
 
     useEffect(() => {
         if (inputRef.current) {
@@ -30,8 +63,37 @@ export function TimerModal(p: props) {
                                 required
                                 type="text"
                                 className="bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-2 px-4 text-gray-700 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
-                            <p className="text-gray-500 text-sm mt-2">Supported formats: <strong>5m</strong> or <strong>30s</strong> or <strong>5m30s</strong></p>
+                            <p className="text-gray-500 text-sm mt-2">Supported formats: <strong>5m</strong> or <strong>30s</strong> or <strong>5m30s</strong> or <strong>1h30m</strong></p>
                         </div>
+                        {boardPresets.length > 0 &&
+                            <div className="flex flex-wrap gap-2 mb-4">
+                                {boardPresets.map(preset =>
+                                    <input key={preset} onClick={() => p.onStart(preset)} type="button" value={preset} className="bg-white hover:bg-gray-100 text-gray-700 font-semibold py-1 px-3 border border-gray-300 rounded-md shadow-sm cursor-pointer" />
+                                )}
+                            </div>
+                        }
+                        {p.canChangeSettings &&
+                            <button type="button" onClick={() => setSettingsOpen(!settingsOpen)} className="text-sky-600 text-sm cursor-pointer">
+                                {settingsOpen ? 'Hide settings' : 'Settings'}
+                            </button>
+                        }
+                        {p.canChangeSettings && settingsOpen &&
+                            <div className="mt-4 space-y-3 text-sm text-gray-700">
+                                <label className="block">
+                                    Presets <span className="text-gray-500">(comma separated, also the phases when auto advance enabled)</span>
+                                    <input onChange={e => setPresets(e.target.value)} value={presets} type="text" className="mt-1 bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-1 px-3 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
+                                </label>
+                                <label className="block">
+                                    Warn before done <span className="text-gray-500">(seconds, 0 to disable)</span>
+                                    <input onChange={e => setWarning(parseInt(e.target.value) || 0)} value={warning} min={0} max={3600} type="number" className="mt-1 bg-gray-200 appearance-none border-2 border-gray-200 rounded-md w-full py-1 px-3 leading-tight focus:outline-none focus:bg-white focus:border-sky-500" />
+                                </label>
+                                <label className="flex items-center gap-2">
+                                    <input onChange={e => setAutoAdvance(e.target.checked)} checked={autoAdvance} type="checkbox" />
+                                    Auto advance to the next preset when done
+                                </label>
+                                <input onClick={saveSettings} type="button" value="Save settings" className="bg-white hover:bg-gray-100 text-gray-700 font-semibold py-1 px-4 border border-gray-300 rounded-md shadow-sm cursor-pointer" />
+                            </div>
+                        }
                         <div className="flex justify-between items-center mt-8 text-right">
                             <div className="flex-1">
                                 <input onClick={p.onCancel} type="button" value="Cancel" className="bg-white hover:bg-gray-100 text-gray-700 font-semibold py-1 px-4 border border-gray-300 rounded-md shadow-sm mr-2" />
@@ -65,9 +127,16 @@ export function useTimerModal(sender: (data: object) => void): [boolean, () => v
         setIsOpen(false)
     }
 
+    function onSaveSettings(settings: TimerSettings) {
+        sender({
+            type: 'board.update',
+            data: settings
+        })
+    }
+
     return [
         isOpen,
         setOpen,
-        { onCancel, onStart }
+        { onCancel, onStart, onSaveSettings }
     ]
 }
@@ -1,10 +1,12 @@
 import type { User, UserConnectionsCount } from "../types"
+import { avatarSrc } from "../avatar"
 
 interface props {
     users: User[]
     conn: UserConnectionsCount
     showStandupBtn: boolean
     showTimerBtn: boolean
+    showColumnBtn: boolean
     onAvatarClick(user: User): void
     onNewStandup(): void
     onNewTimer(): void
@@ -21,7 +23,7 @@ export default function Toolbar(p: props) {
             <div className="flex items-center justify-center gap-2 mb-1">
                 {p.users.map(u => (
                     <div key={u.id} title={u.name} onClick={() => p.onAvatarClick(u)} className="flex flex-col relative isolate items-center justify-center cursor-pointer">
-                        <img src={import.meta.env.BASE_URL + 'avatar/' + u.avatar_id + '.png'} alt="avatar" className="w-12 h-12 rounded-full border-2 border-white shadow-sm" />
+                        <img src={avatarSrc(u)} alt="avatar" className="w-12 h-12 rounded-full border-2 border-white shadow-sm" />
 
                         {numConnections(p.conn, u.id) > 1 &&
                             <span className="absolute top-0 right-0 z-10 flex items-center justify-around w-4 h-4 bg-white rounded-full text-gray-600 shadow text-xs font-semibold">
@@ -48,9 +50,11 @@ export default function Toolbar(p: props) {
                     </button>
                 }
 
-                <button onClick={p.onNewColumn} title="New Column" className="w-12 h-12 flex items-center justify-center text-white shadow rounded-full bg-sky-600 hover:bg-sky-700 z-9 cursor-pointer border-2 border-white">
-                    <svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><path fill="none" stroke="currentColor" strokeLinecap="round" strokeLinejoin="round" strokeWidth="2" d="M5 12h14m-7-7v14" /></svg>
-                </button>
+                {p.showColumnBtn &&
+                    <button onClick={p.onNewColumn} title="New Column" className="w-12 h-12 flex items-center justify-center text-white shadow rounded-full bg-sky-600 hover:bg-sky-700 z-9 cursor-pointer border-2 border-white">
+                        <svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><path fill="none" stroke="currentColor" strokeLinecap="round" strokeLinejoin="round" strokeWidth="2" d="M5 12h14m-7-7v14" /></svg>
+                    </button>
+                }
             </div>
         </div>
     )
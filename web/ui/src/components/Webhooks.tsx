@@ -0,0 +1,77 @@
+import { useState, useEffect } from 'react'
+import { boardPath, requestJSON } from '../api'
+
+interface delivery {
+    id: string
+    event_type: string
+    status_code?: number
+    error?: string
+}
+
+interface webhook {
+    id: string
+    url: string
+    events: string[] | null
+    deliveries: delivery[]
+}
+
+// Webhooks lists webhooks of the board with their latest deliveries, secret of new webhook is only shown once
+export function Webhooks() {
+    const [hooks, setHooks] = useState<webhook[]>([])
+    const [url, setURL] = useState('')
+    const [secret, setSecret] = useState('')
+    const [error, setError] = useState('')
+
+    const load = async () => {
+        const resp = await fetch(boardPath + '/webhooks')
+        if (resp.ok) setHooks(await resp.json())
+    }
+    useEffect(() => { load() }, [])
+
+    const create = async () => {
+        const resp = await requestJSON('POST', boardPath + '/webhooks', { url })
+        if (resp.ok) {
+            const hook: { secret: string } = await resp.json()
+            setSecret(hook.secret)
+            setURL('')
+            setError('')
+            load()
+        } else {
+            setError(await resp.text())
+        }
+    }
+    const remove = async (id: string) => {
+        const resp = await requestJSON('DELETE', boardPath + '/webhooks/' + id)
+        if (resp.ok) load()
+    }
+
+    return (
+        <div className="mb-4 text-sm">
+            <h3 className="font-semibold text-gray-700 mb-2">Webhooks</h3>
+            {hooks.map(h => (
+                <div key={h.id} className="mb-2">
+                    <div className="flex justify-between text-gray-600">
+                        <span className="truncate">{h.url}</span>
+                        <input type="button" value="Remove" onClick={() => remove(h.id)} className="text-red-600 cursor-pointer ml-2" />
+                    </div>
+                    {h.deliveries.slice(0, 3).map(d => (
+                        <div key={d.id} className={"text-xs " + (d.error ? 'text-red-500' : 'text-gray-400')}>
+                            {d.event_type} {d.error || d.status_code}
+                        </div>
+                    ))}
+                </div>
+            ))}
+            <div className="flex gap-2 mt-2">
+                <input
+                    value={url}
+                    onChange={(e) => setURL(e.target.value)}
+                    placeholder="https://example.com/hook"
+                    type="url" className="flex-1 bg-gray-200 border-2 border-gray-200 rounded-md py-1 px-2 text-gray-700 focus:outline-none focus:bg-white focus:border-sky-500" />
+                <input type="button" value="Add" disabled={!url} onClick={create} className="text-sky-600 font-medium cursor-pointer" />
+            </div>
+            {secret && <input readOnly value={secret} onFocus={e => e.target.select()} type="text" className="mt-2 bg-gray-100 border border-gray-200 rounded-md w-full py-1 px-2 text-gray-700" />}
+            {secret && <p className="text-gray-500 mt-1">Signing secret, copy it now as it won't be shown again.</p>}
+            {error && <p className="text-red-600 mt-1">{error}</p>}
+        </div>
+    )
+}
@@ -1,9 +1,12 @@
 import { useCallback, useEffect, useMemo, useState } from 'react'
-import type { Client, UserConnectionsCount, User, Column, Card, ChangeOp, TimerState, Message, MessageList, WSMessage } from './types'
+import type { Board, Client, UserConnectionsCount, User, Column, Card, ChangeOp, TimerState, Message, MessageList, WSMessage } from './types'
+import { playBeep } from './sound'
 
 export interface BoardState {
+    board: Board | null
     currentUser: User | null
     users: User[]
+    spectators: User[]
     userConnectionsCount: UserConnectionsCount
     columns: Column[]
     cards: Card[]
@@ -41,10 +44,21 @@ function applyChangeOperation<T>(list: T[], change: ChangeOp<T>): T[] {
     return newList
 }
 
+// uniqueUsers returns users of the clients, user with multiple connections is listed once
+function uniqueUsers(clients: Client[]): User[] {
+    const uniqueUserIds: string[] = [
+        ...new Set(clients.map((c: Client) => c.user.id)),
+    ]
+    return uniqueUserIds
+        .map((id) => clients.find((c) => c.user.id === id) as Client)
+        .map(c => c.user)
+}
+
 export function useBoardState(
     lastMessage: MessageEvent | null,
     onNotification?: (msg: string) => void,
 ): BoardState {
+    const [board, setBoard] = useState<Board | null>(null)
     const [currentUser, setCurrentUser] = useState<User | null>(null)
     const [clients, setClients] = useState<Client[]>([])
     const [columns, setColumns] = useState<Column[]>([])
@@ -57,6 +71,15 @@ export function useBoardState(
                 setCurrentUser((m as Message).user)
                 break
 
+            case "board":
+                {
+                    const change = m as ChangeOp<Board>
+                    if (change.op === "put" && change.obj) {
+                        setBoard(change.obj)
+                    }
+                }
+                break
+
             case "columns":
                 setColumns(applyChangeOperation(columns, m as ChangeOp<Column>))
                 break
@@ -85,15 +108,24 @@ export function useBoardState(
                 {
                     const msg = m as Message
                     const ts = msg.data as TimerState
-                    setTimerState(ts)
+                    setTimerState({ ...ts, offset: ts.server_time - Date.now() })
                     if (ts.status == 'done') {
+                        // hide the timer unless it's been restarted (e.g auto advanced to next phase)
                         setTimeout(() => {
-                            setTimerState({ ...timerState!, status: 'stopped' })
+                            setTimerState(s => s && s.status == 'done' ? { ...s, status: 'stopped' } : s)
                         }, 5000)
                     }
                 }
                 break
 
+            case "timer.warning":
+                playBeep(1, 660)
+                break
+
+            case "timer.done":
+                playBeep(3)
+                break
+
             default:
                 break
         }
@@ -125,18 +157,15 @@ export function useBoardState(
         )
     }, [clients])
 
-    const users: User[] = useMemo(() => {
-        const uniqueUserIds: string[] = [
-            ...new Set(clients.map((c: Client) => c.user.id)),
-        ]
-        return uniqueUserIds
-            .map((id) => clients.find((c) => c.user.id === id) as Client)
-            .map(c => c.user)
-    }, [clients])
+    // spectators are shown separately, they're not part of the retro (e.g stand-up)
+    const users: User[] = useMemo(() => uniqueUsers(clients.filter(c => !c.spectator)), [clients])
+    const spectators: User[] = useMemo(() => uniqueUsers(clients.filter(c => c.spectator)), [clients])
 
     return {
+        board,
         currentUser,
         users,
+        spectators,
         userConnectionsCount: connectionsCount,
         columns,
         cards,
@@ -0,0 +1,22 @@
+let audioCtx: AudioContext | null = null
+
+// playBeep plays short beeps using Web Audio, browsers may block it until user interacted with the page
+export function playBeep(count: number = 1, frequency: number = 880): void {
+    try {
+        if (!audioCtx) audioCtx = new AudioContext()
+        const ctx = audioCtx
+        for (let i = 0; i < count; i++) {
+            const start = ctx.currentTime + i * 0.3
+            const osc = ctx.createOscillator()
+            const gain = ctx.createGain()
+            osc.frequency.value = frequency
+            gain.gain.setValueAtTime(0.2, start)
+            gain.gain.exponentialRampToValueAtTime(0.001, start + 0.25)
+            osc.connect(gain).connect(ctx.destination)
+            osc.start(start)
+            osc.stop(start + 0.25)
+        }
+    } catch (e) {
+        console.warn('Unable to play sound:', e)
+    }
+}
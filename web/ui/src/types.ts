@@ -8,12 +8,15 @@ export interface User {
     id: string
     name: string
     avatar_id: number
+    avatar_url?: string     // uploaded avatar, or of users signed in via SSO
+    authenticated?: boolean
 }
 
 export interface Client {
     id: string
     user: User
     created_at: number
+    spectator?: boolean // only watches the board
 }
 
 export interface UserConnectionsCount {
@@ -32,15 +35,35 @@ export interface Card {
     id?: string
     created_at?: number
     votes?: number
+    issue_url?: string
 }
 
 export interface TimerState {
     status: string
-    display: string
+    duration: number    // milliseconds
+    ends_at: number     // unix milliseconds, only set when running
+    remaining: number   // milliseconds
+    server_time: number // unix milliseconds
+    started_by: User | null
+    offset?: number     // server_time - local time when state received, to compensate clock skew
+}
+
+export interface Board {
+    id: string
+    created_at: number
+    timer_presets: string[] | null
+    timer_warning: number       // seconds
+    timer_auto_advance: boolean
+    require_auth: boolean       // only signed-in users can join
+    facilitator_id: string      // user who created the board, manages its access
+    private: boolean            // only joined with passcode or invite link
+    access_epoch: number
+    locked: boolean             // nobody can change the board until it's unlocked
+    archived: boolean           // read-only and hidden from board lists
 }
 
 export interface ChangeOp<T> {
-    type: "clients" | "columns" | "cards"
+    type: "board" | "clients" | "columns" | "cards"
     op: "put" | "del"
     id: string
     obj?: T
@@ -57,4 +80,4 @@ export interface MessageList {
     messages: Message[]
 }
 
-export type WSMessage = Message | MessageList | ChangeOp<Client> | ChangeOp<Column> | ChangeOp<Card>
\ No newline at end of file
+export type WSMessage = Message | MessageList | ChangeOp<Board> | ChangeOp<Client> | ChangeOp<Column> | ChangeOp<Card>
\ No newline at end of file